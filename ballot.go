@@ -0,0 +1,141 @@
+package draughts
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Tournament English draughts doesn't start from the usual position, as
+// masters know its best lines too well. Instead an opening is drawn at
+// random, a ballot, and played twice, each player taking each side once.
+// With the three-move ballot, the first three moves are drawn; with the
+// 11-man ballot, one man is taken off each side first.
+
+// Ballot is a tournament opening: men taken off the board, if any, then
+// moves played from the start
+type Ballot struct {
+	Removed []int    // PDN squares of the men taken off
+	Moves   []string // Moves in PDN
+}
+
+// ballotDecks are the decks of ballots the package comes with, as data:
+// one ballot a line, as ParseBallots reads them
+//
+//go:embed ballots/*.txt
+var ballotDecks embed.FS
+
+// ballotDeck reads one of the embedded decks
+func ballotDeck(name string) ([]Ballot, error) {
+	f, err := ballotDecks.Open("ballots/" + name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ballots, err := ParseBallots(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+	return ballots, nil
+}
+
+// ThreeMoveBallots reads the deck of three-move ballots in
+// ballots/three-move.txt
+func ThreeMoveBallots() ([]Ballot, error) {
+	return ballotDeck("three-move.txt")
+}
+
+// ElevenManBallots reads the deck of 11-man ballots in
+// ballots/eleven-man.txt: one man taken off each side at the start of a game
+func ElevenManBallots() ([]Ballot, error) {
+	return ballotDeck("eleven-man.txt")
+}
+
+// String writes the ballot as the removed squares, each with a minus sign,
+// then the moves, like "-4 -29 11-15 23-19"
+func (bl Ballot) String() string {
+	fields := []string{}
+	for _, square := range bl.Removed {
+		fields = append(fields, fmt.Sprintf("-%d", square))
+	}
+
+	return strings.Join(append(fields, bl.Moves...), " ")
+}
+
+// ParseBallot reads a ballot as written by Ballot.String
+func ParseBallot(text string) (Ballot, error) {
+	bl := Ballot{}
+	for _, field := range strings.Fields(text) {
+		if !strings.HasPrefix(field, "-") {
+			bl.Moves = append(bl.Moves, field)
+			continue
+		}
+
+		square, err := strconv.Atoi(field[1:])
+		if err != nil || len(bl.Moves) > 0 {
+			return Ballot{}, fmt.Errorf("Bad ballot '%s'", text)
+		}
+		bl.Removed = append(bl.Removed, square)
+	}
+
+	if _, err := NewGameFromBallot(English, bl); err != nil {
+		return Ballot{}, fmt.Errorf("Ballot '%s': %s", text, err)
+	}
+	return bl, nil
+}
+
+// ParseBallots reads a deck of ballots, one a line. Blank lines, and
+// anything after a #, are ignored.
+func ParseBallots(r io.Reader) ([]Ballot, error) {
+	ballots := []Ballot{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		bl, err := ParseBallot(text)
+		if err != nil {
+			return nil, err
+		}
+		ballots = append(ballots, bl)
+	}
+
+	return ballots, scanner.Err()
+}
+
+// DrawBallot picks one of `ballots` at random
+func DrawBallot(ballots []Ballot, rng *rand.Rand) Ballot {
+	return ballots[rng.Intn(len(ballots))]
+}
+
+// NewGameFromBallot sets up a game of variant `v` with the ballot's men
+// taken off and its moves played. Which player takes which side is up to
+// whoever runs the match: each ballot is usually played twice, with the
+// colours reversed.
+func NewGameFromBallot(v *Variant, bl Ballot) (*Game, error) {
+	board := NewVariantBoard(v)
+	for _, square := range bl.Removed {
+		if square < 1 || square > v.SquareCount() || board.Get(v.SquarePos(square)) == 0 {
+			return nil, fmt.Errorf("No man on %d to take off", square)
+		}
+		board.Set(v.SquarePos(square), 0)
+	}
+
+	game := NewGameFromPosition(board, v.FirstPlayer)
+	for _, text := range bl.Moves {
+		move, err := game.Board().ResolveMove(text, game.Player())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", text, err)
+		}
+		game.Play(move)
+	}
+
+	return game, nil
+}
@@ -0,0 +1,85 @@
+package draughts
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestThreeMoveBallots(t *testing.T) {
+	ballots, err := ThreeMoveBallots()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every line perft counts three plies in, until the ACF's deck of 156
+	// takes their place
+	if len(ballots) != 302 {
+		t.Errorf("Expected 302 ballots, found %d", len(ballots))
+	}
+
+	seen := map[string]bool{}
+	for _, bl := range ballots {
+		game, err := NewGameFromBallot(English, bl)
+		if err != nil {
+			t.Fatalf("%s: %s", bl, err)
+		}
+		if game.Ply() != 3 || game.Player() != 2 {
+			t.Errorf("%s: expected Green to move after 3 plies, found %d to move after %d",
+				bl, game.Player(), game.Ply())
+		}
+		if seen[bl.String()] {
+			t.Errorf("%s is listed twice", bl)
+		}
+		seen[bl.String()] = true
+	}
+}
+
+func TestElevenManBallots(t *testing.T) {
+	ballots, err := ElevenManBallots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ballots) != 144 {
+		t.Fatalf("Expected 144 ballots, found %d", len(ballots))
+	}
+
+	game, err := NewGameFromBallot(English, ballots[143])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fen := game.Board().FEN(game.Player()); fen != "B:W21,22,23,24,25,26,27,28,29,30,31:B1,2,3,4,5,6,7,8,9,10,11" {
+		t.Errorf("Expected 12 and 32 to be taken off, found %s", fen)
+	}
+}
+
+func TestParseBallots(t *testing.T) {
+	deck := `# Two from the deck
+9-13 21-17 5-9
+11-15 22-18 15x22   # The single corner, with a capture
+
+-4 -29 11-15
+`
+	ballots, err := ParseBallots(strings.NewReader(deck))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ballots) != 3 || ballots[1].String() != "11-15 22-18 15x22" || ballots[2].String() != "-4 -29 11-15" {
+		t.Errorf("Expected 3 ballots, found %v", ballots)
+	}
+
+	for _, text := range []string{"11-15 -4", "-x", "11-16 22-18 15x22", "-33", "-13"} {
+		if _, err := ParseBallot(text); err == nil {
+			t.Errorf("Expected an error parsing '%s'", text)
+		}
+	}
+
+	if _, err := ballotDeck("missing.txt"); err == nil {
+		t.Errorf("Expected an error reading a deck that isn't there")
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	if bl := DrawBallot(ballots, rng); bl.String() == "" {
+		t.Errorf("Expected a ballot to be drawn")
+	}
+}
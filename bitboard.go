@@ -0,0 +1,247 @@
+package draughts
+
+import "math/bits"
+
+// Bitboard holds a position as three masks over the 32 playable squares,
+// where bit n is PDN square n+1. So bits 0-3 are the top row of the board,
+// bits 28-31 the bottom row. Whether a piece is a man or a king is given by
+// the Kings mask.
+//
+// Moving one square diagonally is a shift: by 4 squares, plus or minus one
+// depending on whether the row is odd or even. The masks below guard the
+// edges of the board, where a shift would wrap around.
+type Bitboard struct {
+	Red   uint32 // Player 1
+	Green uint32 // Player 2
+	Kings uint32
+}
+
+const (
+	evenRows = 0x0F0F0F0F // Rows 0, 2, 4, 6; squares in X = 1, 3, 5, 7
+	oddRows  = 0xF0F0F0F0 // Rows 1, 3, 5, 7; squares in X = 0, 2, 4, 6
+	firstRow = 0x0000000F
+	lastRow  = 0xF0000000
+	leftCol  = 0x11111111 // Leftmost square of each row
+	rightCol = 0x88888888 // Rightmost square of each row
+)
+
+// diagonal is one of the four directions a piece can move in
+type diagonal int
+
+const (
+	downLeft diagonal = iota
+	downRight
+	upLeft
+	upRight
+)
+
+// dY is the change in row when moving along diagonal `d`
+func (d diagonal) dY() int {
+	if d == downLeft || d == downRight {
+		return 1
+	}
+	return -1
+}
+
+// step moves every square in `squares` one square along diagonal `d`.
+// Squares that would fall off the board are dropped.
+func step(squares uint32, d diagonal) uint32 {
+	switch d {
+	case downLeft:
+		return (squares&evenRows)<<4 | (squares&oddRows&^leftCol&^lastRow)<<3
+	case downRight:
+		return (squares&evenRows&^rightCol)<<5 | (squares&oddRows&^lastRow)<<4
+	case upLeft:
+		return (squares&evenRows&^firstRow)>>4 | (squares&oddRows&^leftCol)>>5
+	default: // upRight
+		return (squares&evenRows&^firstRow&^rightCol)>>3 | (squares&oddRows)>>4
+	}
+}
+
+// playerDiagonals lists the directions for each player, men then kings,
+// forward first
+var playerDiagonals = [3][2][]diagonal{
+	1: {{downLeft, downRight}, {downLeft, downRight, upLeft, upRight}},
+	2: {{upLeft, upRight}, {upLeft, upRight, downLeft, downRight}},
+}
+
+// diagonals returns the directions a piece may move in
+func diagonals(player int, king bool) []diagonal {
+	if king {
+		return playerDiagonals[player][1]
+	}
+	return playerDiagonals[player][0]
+}
+
+// squareBit returns the bit for the square at `p`, or 0 for the unplayable
+// squares
+func squareBit(p Pos) uint32 {
+	if (p.X+p.Y)%2 == 0 {
+		return 0
+	}
+	return 1 << uint(p.Y*4+p.X/2)
+}
+
+// bitPositions maps bit number to board position
+var bitPositions [32]Pos
+
+func init() {
+	for n := range bitPositions {
+		bitPositions[n] = NewPosFromSquareID(n + 1)
+	}
+}
+
+// bitPos is the inverse of squareBit
+func bitPos(bit uint32) Pos {
+	return bitPositions[bits.TrailingZeros32(bit)]
+}
+
+// set records `piece` on square `p`, as Board.Set
+func (bb *Bitboard) set(p Pos, piece int) {
+	bit := squareBit(p)
+	bb.Red &^= bit
+	bb.Green &^= bit
+	bb.Kings &^= bit
+
+	switch abs(piece) {
+	case 1:
+		bb.Red |= bit
+	case 2:
+		bb.Green |= bit
+	}
+
+	if piece < 0 {
+		bb.Kings |= bit
+	}
+}
+
+// pieces returns the mask of squares held by `player`
+func (bb Bitboard) pieces(player int) uint32 {
+	if player == 1 {
+		return bb.Red
+	}
+	return bb.Green
+}
+
+// empty returns the mask of unoccupied squares
+func (bb Bitboard) empty() uint32 {
+	return ^(bb.Red | bb.Green)
+}
+
+// CanCapture returns true if `player` has a capture available. All pieces
+// are tested at once, one diagonal at a time.
+func (bb Bitboard) CanCapture(player int) bool {
+	own := bb.pieces(player)
+	opp := bb.pieces(Opposition(player))
+	empty := bb.empty()
+
+	for _, d := range diagonals(player, true) {
+		movers := own
+		if d.dY() != direction(player) {
+			movers &= bb.Kings // Only kings go backwards
+		}
+
+		if step(step(movers, d)&opp, d)&empty != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Moves returns a list of all valid moves for `player`, as Board.AllMoves
+func (bb Bitboard) Moves(player int) []*Move {
+	own := bb.pieces(player)
+
+	if bb.CanCapture(player) {
+		moves := []*Move{}
+		for pieces := own; pieces != 0; pieces &= pieces - 1 {
+			bit := pieces & -pieces
+			bb.jumps(player, bit, bb.Kings&bit != 0, 0, NewMove(player, bitPos(bit)), &moves)
+		}
+		return moves
+	}
+
+	moves := []*Move{}
+	empty := bb.empty()
+	for pieces := own; pieces != 0; pieces &= pieces - 1 {
+		bit := pieces & -pieces
+		from := bitPos(bit)
+		for _, d := range diagonals(player, bb.Kings&bit != 0) {
+			if to := step(bit, d) & empty; to != 0 {
+				moves = append(moves, NewMove(player, from, bitPos(to)))
+			}
+		}
+	}
+
+	return moves
+}
+
+// jumps walks the tree of capture sequences depth-first from the piece on
+// `bit`, appending each complete sequence to `moves`. Pieces captured so
+// far, in `captured`, stay on the board until the move is complete: they
+// can't be jumped again, nor landed on.
+func (bb Bitboard) jumps(player int, bit uint32, king bool, captured uint32, move *Move, moves *[]*Move) {
+	opp := bb.pieces(Opposition(player)) &^ captured
+	empty := bb.empty()
+
+	found := false
+	for _, d := range diagonals(player, king) {
+		over := step(bit, d) & opp
+		if over == 0 {
+			continue
+		}
+
+		land := step(over, d) & empty
+		if land == 0 {
+			continue
+		}
+
+		found = true
+		branch := NewMove(player, move.Squares...)
+		branch.addSquare(bitPos(land))
+
+		// A man reaching the far side is crowned, which ends the move
+		if !king && land&crownMask(player) != 0 {
+			*moves = append(*moves, branch)
+			continue
+		}
+
+		next := bb
+		next.remove(bit)
+		next.place(player, land, king)
+		next.jumps(player, land, king, captured|over, branch, moves)
+	}
+
+	if !found && move.Length() > 1 {
+		*moves = append(*moves, move)
+	}
+}
+
+// crownMask is the row on which `player`'s men are crowned
+func crownMask(player int) uint32 {
+	if player == 1 {
+		return lastRow
+	}
+	return firstRow
+}
+
+// remove takes whatever is on the `bit` squares off the board
+func (bb *Bitboard) remove(bit uint32) {
+	bb.Red &^= bit
+	bb.Green &^= bit
+	bb.Kings &^= bit
+}
+
+// place puts a piece for `player` on the `bit` squares
+func (bb *Bitboard) place(player int, bit uint32, king bool) {
+	if player == 1 {
+		bb.Red |= bit
+	} else {
+		bb.Green |= bit
+	}
+
+	if king {
+		bb.Kings |= bit
+	}
+}
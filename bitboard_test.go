@@ -0,0 +1,141 @@
+package draughts
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestStep(t *testing.T) {
+	type stepTest struct {
+		from     int // PDN square
+		d        diagonal
+		expected int // PDN square, 0 for off the board
+	}
+
+	var stepTests = []stepTest{
+		{1, downLeft, 5}, {1, downRight, 6}, {1, upLeft, 0}, {1, upRight, 0},
+		{4, downLeft, 8}, {4, downRight, 0},
+		{5, downLeft, 0}, {5, downRight, 9}, {5, upLeft, 0}, {5, upRight, 1},
+		{12, downLeft, 16}, {12, downRight, 0}, {12, upLeft, 8}, {12, upRight, 0},
+		{14, downLeft, 17}, {14, downRight, 18}, {14, upLeft, 9}, {14, upRight, 10},
+		{18, downLeft, 22}, {18, downRight, 23}, {18, upLeft, 14}, {18, upRight, 15},
+		{29, downLeft, 0}, {29, downRight, 0}, {29, upLeft, 0}, {29, upRight, 25},
+		{32, downRight, 0}, {32, upLeft, 27}, {32, upRight, 28},
+	}
+
+	for _, tt := range stepTests {
+		actual := step(1<<uint(tt.from-1), tt.d)
+		expected := uint32(0)
+		if tt.expected != 0 {
+			expected = 1 << uint(tt.expected-1)
+		}
+		if actual != expected {
+			t.Errorf("step(%d, %d): expected %032b, actual %032b",
+				tt.from, tt.d, expected, actual)
+		}
+	}
+}
+
+func TestBitboardSync(t *testing.T) {
+	board := NewBoard()
+	if board.bb.Red != 0x00000FFF || board.bb.Green != 0xFFF00000 || board.bb.Kings != 0 {
+		t.Errorf("Unexpected initial bitboard %+v", board.bb)
+	}
+
+	board.Set(NewPosFromSquareID(18), -2)
+	board.Set(NewPosFromSquareID(1), 0)
+	if board.bb.Green&board.bb.Kings != 1<<17 || board.bb.Red&1 != 0 {
+		t.Errorf("Bitboard not updated by Set %+v", board.bb)
+	}
+}
+
+// moveStrings returns a sorted list of moves in text form, so that lists
+// from different generators can be compared
+func moveStrings(moves []*Move) []string {
+	list := []string{}
+	for _, move := range moves {
+		list = append(list, move.AsString())
+	}
+	sort.Strings(list)
+
+	return list
+}
+
+// randomBoard scatters men and kings of both colours over the board
+func randomBoard(rng *rand.Rand) *Board {
+	board := &Board{}
+	pieces := []int{1, 2, -1, -2}
+	for square := 1; square <= 32; square++ {
+		if rng.Intn(3) == 0 {
+			board.Set(NewPosFromSquareID(square), pieces[rng.Intn(len(pieces))])
+		}
+	}
+
+	return board
+}
+
+// sameMoves cross-checks the bitboard generator against the array one
+func sameMoves(t *testing.T, board *Board, player int) bool {
+	expected := moveStrings(board.arrayMoves(player))
+	actual := moveStrings(board.AllMoves(player))
+
+	if len(expected) != len(actual) {
+		t.Errorf("Expected %d moves, found %d\n%v\n%v",
+			len(expected), len(actual), expected, actual)
+		return false
+	}
+
+	for i := range expected {
+		if expected[i] != actual[i] {
+			t.Errorf("Expected move %s, found %s", expected[i], actual[i])
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestBitboardMatchesArrayRandomPositions(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		board := randomBoard(rng)
+		if !sameMoves(t, board, 1) || !sameMoves(t, board, 2) {
+			t.Fatalf("Generators disagree on random position %d", i)
+		}
+	}
+}
+
+func TestBitboardMatchesArrayRandomGames(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for game := 0; game < 100; game++ {
+		board := NewBoard()
+		player := 1
+		for ply := 0; ply < 200; ply++ {
+			if !sameMoves(t, board, player) {
+				t.Fatalf("Generators disagree in game %d at ply %d", game, ply)
+			}
+
+			moves := board.AllMoves(player)
+			if len(moves) == 0 {
+				break
+			}
+			board = board.Apply(moves[rng.Intn(len(moves))])
+			player = Opposition(player)
+		}
+	}
+}
+
+func BenchmarkAllMoves(b *testing.B) {
+	board := NewBoard()
+	for i := 0; i < b.N; i++ {
+		board.AllMoves(1)
+	}
+}
+
+func BenchmarkArrayMoves(b *testing.B) {
+	board := NewBoard()
+	for i := 0; i < b.N; i++ {
+		board.arrayMoves(1)
+	}
+}
@@ -1,11 +1,15 @@
-package main
+package draughts
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
-// PieceCount is the count of mens and kings
+// PieceCount is the count of men and kings, indexed by player-1
 type PieceCount struct {
-	men   [2]int
-	kings [2]int
+	Men   [2]int
+	Kings [2]int
 }
 
 // Opposition is the other player
@@ -25,27 +29,76 @@ func direction(player int) int {
 	return -1
 }
 
-// Board represents the state of the game as an 8x8 array of integers
-// where player 1 is the value 1, player 2 the value 2 and an empty
-// space is a 0
+// diagonalSteps are the four directions along the diagonals, as (dX, dY)
+// for player 1, forwards first; player 2 has dY flipped
+var diagonalSteps = []Pos{{-1, 1}, {1, 1}, {-1, -1}, {1, -1}}
+
+// Board represents the state of the game as an array of integers, of
+// which the variant's Size x Size are used, where player 1 is the value 1,
+// player 2 the value 2 and an empty space is a 0. For 8x8 boards the same
+// position is also held as a bitboard, used for move generation, and for
+// all boards as a Zobrist hash; both are kept up to date incrementally as
+// squares change. The zero value is an empty English draughts board.
 type Board struct {
-	state [8][8]int
+	state   [MaxSize][MaxSize]int
+	variant *Variant
+	bb      Bitboard
+	hash    uint64
 }
 
-// NewBoard sets up a fresh board in the initial configuration
+// NewBoard sets up a fresh English draughts board in the initial
+// configuration
 func NewBoard() *Board {
-	b := &Board{}
-	b.state[0] = [8]int{0, 1, 0, 1, 0, 1, 0, 1}
-	b.state[1] = [8]int{1, 0, 1, 0, 1, 0, 1, 0}
-	b.state[2] = [8]int{0, 1, 0, 1, 0, 1, 0, 1}
+	return NewVariantBoard(English)
+}
+
+// NewVariantBoard sets up a fresh board for variant `v` in the initial
+// configuration: each player has men on the dark squares of all but the
+// middle two rows on their side of the board
+func NewVariantBoard(v *Variant) *Board {
+	b := &Board{variant: v}
+	rows := v.Size/2 - 1
+	for y := 0; y < v.Size; y++ {
+		for x := 0; x < v.Size; x++ {
+			pos := Pos{x, y}
+			if !playable(pos) {
+				continue
+			}
 
-	b.state[5] = [8]int{2, 0, 2, 0, 2, 0, 2, 0}
-	b.state[6] = [8]int{0, 2, 0, 2, 0, 2, 0, 2}
-	b.state[7] = [8]int{2, 0, 2, 0, 2, 0, 2, 0}
+			if y < rows {
+				b.Set(pos, 1)
+			} else if y >= v.Size-rows {
+				b.Set(pos, 2)
+			}
+		}
+	}
 
 	return b
 }
 
+// NewBoardFromArray sets up an English draughts board from an array of
+// square values, rows (Y) by cols (X), as held by Board
+func NewBoardFromArray(state [8][8]int) *Board {
+	b := &Board{}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if state[y][x] != 0 {
+				b.Set(Pos{x, y}, state[y][x])
+			}
+		}
+	}
+
+	return b
+}
+
+// Variant returns the rules the board is played by
+func (b Board) Variant() *Variant {
+	if b.variant == nil {
+		return English
+	}
+	return b.variant
+}
+
 // Get returns the state of the board Get position `p`
 func (b Board) Get(p Pos) int {
 	return b.state[p.Y][p.X]
@@ -53,87 +106,232 @@ func (b Board) Get(p Pos) int {
 
 // Set sets the the state of the board at position `p`
 func (b *Board) Set(p Pos, state int) {
+	b.hash ^= zobristPiece(p, b.state[p.Y][p.X]) ^ zobristPiece(p, state)
+	if b.Variant().Size == 8 {
+		b.bb.set(p, state)
+	}
 	b.state[p.Y][p.X] = state
 }
 
+// Bitboard returns the position as a bitboard. Only 8x8 boards have one.
+func (b Board) Bitboard() Bitboard {
+	return b.bb
+}
+
 // Apply moves a piece according to `move` and returns a new
 // state. The move must be legal.
 func (b Board) Apply(move *Move) *Board {
+	newBoard := &b
+	newBoard.MakeMove(move)
+	return newBoard
+}
+
+// Undo records what MakeMove changed, for UnmakeMove to put back
+type Undo struct {
+	move     *Move
+	piece    int // The piece that moved, as it was before
+	captured []capturedPiece
+	crowned  bool
+	hash     uint64 // The hash before the move
+}
+
+// capturedPiece is a piece taken off the board, and where it stood
+type capturedPiece struct {
+	square Pos
+	piece  int
+}
+
+// MakeMove plays `move` on the board in place, rather than copying it as
+// Apply does, and returns what UnmakeMove needs to take it back. The move
+// must be legal.
+func (b *Board) MakeMove(move *Move) Undo {
+	v := b.Variant()
 	startPos := move.Squares[0]
-	startPiece := b.Get(startPos)
-	newBoard := &Board{b.state}
+	undo := Undo{move: move, piece: b.Get(startPos), hash: b.hash}
+	for _, square := range b.CapturedSquares(move) {
+		undo.captured = append(undo.captured, capturedPiece{square, b.Get(square)})
+	}
 
 	// Blank out the starting square of the move
-	newBoard.Set(startPos, 0)
+	b.Set(startPos, 0)
 
 	// Remove any captured pieces we jumped from the board
-	for _, square := range move.JumpedSquares() {
-		newBoard.Set(square, 0)
+	for _, c := range undo.captured {
+		b.Set(c.square, 0)
 	}
 
 	// Land on the final square
 	final := move.Squares[move.Length()-1]
 
-	// If not already a king, and we've landed on either baseline
-	// we get a coronation.
-	if startPiece > 0 && final.Y == 0 || final.Y == 7 {
-		newBoard.Set(final, -startPiece) // Coronation
+	// If not already a king, and we've landed on the far side of the
+	// board we get a coronation. In some variants, just passing through
+	// the far side during a capture is enough.
+	crowned := final.Y == v.crownRow(move.Player)
+	if v.PromoteDuringCapture {
+		for _, square := range move.Squares[1:] {
+			crowned = crowned || square.Y == v.crownRow(move.Player)
+		}
+	}
+
+	undo.crowned = undo.piece > 0 && crowned
+	if undo.crowned {
+		b.Set(final, -undo.piece) // Coronation
 	} else {
-		newBoard.Set(final, startPiece)
+		b.Set(final, undo.piece)
 	}
 
-	return newBoard
+	return undo
+}
+
+// UnmakeMove takes back the move MakeMove made, which must be the last one
+// made on the board
+func (b *Board) UnmakeMove(undo Undo) {
+	b.Set(undo.move.Squares[undo.move.Length()-1], 0)
+	for _, c := range undo.captured {
+		b.Set(c.square, c.piece)
+	}
+	b.Set(undo.move.Squares[0], undo.piece)
+	b.hash = undo.hash
+}
+
+// CapturedSquares returns the squares of the pieces captured by `move`:
+// any piece found between two consecutive squares of the move. Kings that
+// fly capture pieces some distance away, so unlike Move.JumpedSquares this
+// needs to look at the board.
+func (b Board) CapturedSquares(move *Move) []Pos {
+	captured := []Pos{}
+	for index := 0; index < move.Length()-1; index++ {
+		captured = append(captured,
+			b.piecesBetween(move.Squares[index], move.Squares[index+1], move.Squares[0])...)
+	}
+
+	return captured
+}
+
+// piecesBetween returns the squares holding pieces on the diagonal between
+// `from` and `to`. The moving piece has left `origin`, so that doesn't
+// count.
+func (b Board) piecesBetween(from, to, origin Pos) []Pos {
+	dX, dY := to.X-from.X, to.Y-from.Y
+	if abs(dX) != abs(dY) {
+		return nil // Not a diagonal; not a legal move either
+	}
+
+	pieces := []Pos{}
+	step := Pos{sign(dX), sign(dY)}
+	for p := (Pos{from.X + step.X, from.Y + step.Y}); p != to; p = (Pos{p.X + step.X, p.Y + step.Y}) {
+		if b.Get(p) != 0 && p != origin {
+			pieces = append(pieces, p)
+		}
+	}
+
+	return pieces
+}
+
+// MoveString returns `move` in the standard notation: square numbers
+// separated by '-' for a plain move, or 'x' for a capture
+func (b Board) MoveString(move *Move) string {
+	separator := "-"
+	if b.IsCapture(move) {
+		separator = "x"
+	}
+
+	squares := []string{}
+	for _, square := range move.Squares {
+		squares = append(squares, strconv.Itoa(b.Variant().SquareNumber(square)))
+	}
+
+	return strings.Join(squares, separator)
+}
+
+// IsCapture returns true if `move` captures any pieces
+func (b Board) IsCapture(move *Move) bool {
+	return move.Length() > 1 && len(b.CapturedSquares(move)) > 0
 }
 
 // Validate traverses a chain of squares to dermine if the move is legal
 func (b Board) Validate(move *Move) error {
+	v := b.Variant()
+	if move.Length() < 2 {
+		return fmt.Errorf("Move must visit at least two squares")
+	}
+
 	// Start square has to be held by `player`
 	startPos := move.Squares[0]
 	startPiece := b.Get(startPos)
-	if abs(startPiece) != move.Player {
+	if !v.onBoard(startPos) || abs(startPiece) != move.Player {
 		return fmt.Errorf("Start position %s isn't valid", startPos.AsString())
 	}
 
-	// If we have more that one transition (more than two squares), then all
-	// transitions must be jumps. Note, this doesn't verify the actual state
-	// of the board, just the coordinates.
-	if move.Length() > 2 && !move.ValidJumpSequence() {
-		return fmt.Errorf("Move is not a valid jump sequence")
-	}
-
+	origin := startPos
+	king := startPiece < 0
+	jumped := map[Pos]bool{}
 	for index := 1; index < move.Length(); index++ {
 		endPos := move.Squares[index]
+		if !v.onBoard(endPos) {
+			return fmt.Errorf("Position %s is off the board", endPos.AsString())
+		}
 
-		// endPos has to be available
-		if b.Get(endPos) != 0 {
+		// endPos has to be available. The square we started from was
+		// vacated as we left it, so a king may come back to it.
+		if b.Get(endPos) != 0 && endPos != origin {
 			return fmt.Errorf("Position %s isn't available", endPos.AsString())
 		}
 
-		// Check that we moved diagonally, either 1 or 2 squares
-		dX, dY, err := ValidDiagonal(startPos, endPos)
-		if err != nil {
-			return err
+		// Check that we moved diagonally, and see what we passed over
+		dX, dY := endPos.X-startPos.X, endPos.Y-startPos.Y
+		if dX == 0 || abs(dX) != abs(dY) {
+			return fmt.Errorf("Move is not diagonal")
 		}
 
-		// Check we're moving forwards, if not king. King-case is covered
-		// by the above diagonal check.
-		if dY > 0 && startPiece == 2 || dY < 0 && startPiece == 1 {
-			return fmt.Errorf("Non-king move must move forward")
-		}
+		over := b.piecesBetween(startPos, endPos, origin)
+		distance := abs(dX)
+		forward := sign(dY) == direction(move.Player)
 
-		// If I jumped a square, it has to be occupied by the opponent.
-		betweenX := dX / 2
-		betweenY := dY / 2
+		switch {
+		case len(over) == 0:
+			// A plain move can't be part of a sequence of captures
+			if move.Length() > 2 {
+				return fmt.Errorf("Move is not a valid jump sequence")
+			}
 
-		middle := Pos{
-			startPos.X + betweenX,
-			startPos.Y + betweenY,
-		}
+			if distance > 1 && !(king && v.FlyingKings) {
+				return fmt.Errorf("Incorrect distance for valid diagonal move")
+			}
+
+			// Check we're moving forwards, if not king
+			if !king && !forward {
+				return fmt.Errorf("Non-king move must move forward")
+			}
+
+		case len(over) == 1:
+			middle := over[0]
+			if distance > 2 && !(king && v.FlyingKings) {
+				return fmt.Errorf("Incorrect distance for valid capture")
+			}
+
+			if !king && !forward && !v.MenCaptureBackward {
+				return fmt.Errorf("Non-king move must move forward")
+			}
 
-		if betweenX != 0 && betweenY != 0 {
+			// The square jumped has to be occupied by the opponent, and each
+			// piece can only be captured once.
 			if abs(b.Get(middle)) != Opposition(move.Player) {
 				return fmt.Errorf("Jumped square not held by opponent")
 			}
+
+			if jumped[middle] {
+				return fmt.Errorf("Piece at %s can't be captured twice", middle.AsString())
+			}
+			jumped[middle] = true
+
+		default:
+			return fmt.Errorf("Can't jump more than one piece at a time")
+		}
+
+		// A man reaching the far side may be crowned there and then
+		if !king && v.PromoteDuringCapture && endPos.Y == v.crownRow(move.Player) {
+			king = true
 		}
 
 		startPos = endPos
@@ -142,93 +340,144 @@ func (b Board) Validate(move *Move) error {
 	return nil // we're good
 }
 
+// directions lists the diagonals a piece may move along, as (dX, dY),
+// forward first. Men move forwards, but may capture backwards in some
+// variants; kings go either way.
+func (b Board) directions(player int, king, capture bool) []Pos {
+	count := 2
+	if king || capture && b.Variant().MenCaptureBackward {
+		count = 4
+	}
+
+	dirs := make([]Pos, count)
+	for i := range dirs {
+		dirs[i] = Pos{diagonalSteps[i].X, diagonalSteps[i].Y * direction(player)}
+	}
+
+	return dirs
+}
+
 // nonCaptureMoves returns a list of non capture moves from `square` --
-// 0, 1, 2 (4, 5 for kings) possible squares.
+// 0, 1, 2 (4, 5 for kings) possible squares, or any distance along a free
+// diagonal for flying kings.
 func (b Board) nonCaptureMoves(player int, square Pos) []*Move {
-	cols := []int{-1, 1}
-	rows := []int{direction(player)}
-	if b.Get(square) < 0 {
-		// Kings can go both backwards and forwards
-		rows = append(rows, -direction(player))
-	}
+	v := b.Variant()
+	king := b.Get(square) < 0
 
 	moves := []*Move{}
-	for _, dY := range rows {
-		for _, dX := range cols {
-			if candidate, err := NewPos(square.X+dX, square.Y+dY); err == nil {
-				if b.Get(*candidate) == 0 {
-					moves = append(moves, NewMove(player, square, *candidate))
-				}
+	for _, d := range b.directions(player, king, false) {
+		candidate := Pos{square.X + d.X, square.Y + d.Y}
+		for v.onBoard(candidate) && b.Get(candidate) == 0 {
+			moves = append(moves, NewMove(player, square, candidate))
+			if !(king && v.FlyingKings) {
+				break
 			}
+			candidate = Pos{candidate.X + d.X, candidate.Y + d.Y}
 		}
 	}
 
 	return moves
 }
 
+// jump is a single capture: the square of the piece jumped over, and the
+// square landed on
+type jump struct {
+	over, land Pos
+}
+
+// jumps returns all single jumps from `square` by a man or king. Pieces in
+// `captured` were taken earlier in the move: they stay on the board until
+// it's complete, so they can't be jumped again, and can't be landed on.
+func (b Board) jumps(player int, square Pos, king bool, captured []Pos) []jump {
+	v := b.Variant()
+	flying := king && v.FlyingKings
+
+	list := []jump{}
+	for _, d := range b.directions(player, king, true) {
+		// A flying king can come from a distance
+		over := Pos{square.X + d.X, square.Y + d.Y}
+		for flying && v.onBoard(over) && b.Get(over) == 0 {
+			over = Pos{over.X + d.X, over.Y + d.Y}
+		}
+
+		if !v.onBoard(over) || abs(b.Get(over)) != Opposition(player) ||
+			containsPos(captured, over) {
+			continue
+		}
+
+		// ..and land at any distance beyond
+		land := Pos{over.X + d.X, over.Y + d.Y}
+		for v.onBoard(land) && b.Get(land) == 0 {
+			list = append(list, jump{over, land})
+			if !flying {
+				break
+			}
+			land = Pos{land.X + d.X, land.Y + d.Y}
+		}
+	}
+
+	return list
+}
+
 // singleJumps returns a list of single jumpable positions from `square` --
 // 0, 1, 2 (4, 5 for kings) possible squares
 func (b Board) singleJumps(player int, square Pos) []Pos {
-	cols := []int{-1, 1}
-	rows := []int{direction(player)}
-	if b.Get(square) < 0 {
-		// Kings can go both backwards and forwards
-		rows = append(rows, -direction(player))
-	}
-
-	jumps := []Pos{}
-
-	for _, dY := range rows {
-		for _, dX := range cols {
-			if first, err := NewPos(square.X+dX, square.Y+dY); err == nil {
-				if abs(b.Get(*first)) == Opposition(player) {
-					if second, err := NewPos(first.X+dX, first.Y+dY); err == nil {
-						if b.Get(*second) == 0 {
-							jumps = append(jumps, *second)
-						}
-					}
-				}
-			}
-		}
+	landings := []Pos{}
+	for _, jmp := range b.jumps(player, square, b.Get(square) < 0, nil) {
+		landings = append(landings, jmp.land)
 	}
 
-	return jumps
+	return landings
 }
 
 // JumpMoves finds all available capture moves starting at `square`, including
 // multi-hops and king moves
 func (b Board) JumpMoves(player int, square Pos) []*Move {
 	movesList := []*Move{}
-	b.jumpMoves(&Board{b.state}, square, NewMove(player, square), &movesList)
+	b.jumpMoves(square, b.Get(square) < 0, nil, NewMove(player, square), &movesList)
 
 	return movesList
 }
 
-// validDirection discards potential jump squares based on the prevaling
-// direction already set in the move. This is to ensure that kings, whilst
-// allowed to go both forward and back still stick to a single direction
-// within each single move.
-func validDirection(jumps []Pos, move *Move) []Pos {
-	if move.Length() == 1 {
-		// We have no prevaling direction, so any jumps may be considered
-		return jumps
+// continuing narrows down the landing squares for a flying king: if it can
+// carry on capturing from some of the squares beyond a piece, it has to
+// land on one of those.
+func (b *Board) continuing(player int, square Pos, captured []Pos, jumps []jump) []jump {
+	canContinue := map[jump]bool{}
+	mustContinue := map[Pos]bool{} // By piece jumped
+	piece := b.Get(square)
+	b.Set(square, 0)
+	for _, jmp := range jumps {
+		b.Set(jmp.land, -player)
+		if len(b.jumps(player, jmp.land, true, append(captured[:len(captured):len(captured)], jmp.over))) > 0 {
+			canContinue[jmp] = true
+			mustContinue[jmp.over] = true
+		}
+		b.Set(jmp.land, 0)
 	}
+	b.Set(square, piece)
 
-	dY := move.Squares[1].Y - move.Squares[0].Y
-
-	sameDirection := []Pos{}
+	list := []jump{}
 	for _, jmp := range jumps {
-		if jmp.Y-move.Squares[1].Y == dY {
-			sameDirection = append(sameDirection, jmp)
+		if canContinue[jmp] || !mustContinue[jmp.over] {
+			list = append(list, jmp)
 		}
 	}
 
-	return sameDirection
+	return list
 }
 
-func (b Board) jumpMoves(state *Board, square Pos, move *Move, moves *[]*Move) {
+// jumpMoves extends `move`, which has brought the piece to `square`, by
+// every available jump. The board `b` has the piece moved to `square`, but
+// still holds the pieces captured so far. A king may change direction
+// between jumps. Each jump is made on `b` and taken back afterwards.
+func (b *Board) jumpMoves(square Pos, king bool, captured []Pos, move *Move, moves *[]*Move) {
+	v := b.Variant()
 	player := move.Player
-	jumps := validDirection(b.singleJumps(player, square), move)
+	jumps := b.jumps(player, square, king, captured)
+	if king && v.FlyingKings {
+		jumps = b.continuing(player, square, captured, jumps)
+	}
 
 	if len(jumps) == 0 && move.Length() > 1 {
 		*moves = append(*moves, move)
@@ -236,21 +485,49 @@ func (b Board) jumpMoves(state *Board, square Pos, move *Move, moves *[]*Move) {
 	}
 
 	for _, jmp := range jumps {
-		newState := state.Apply(NewMove(player, square, jmp))
-		move.addSquare(jmp)                     // Record this in current move..
-		b.jumpMoves(newState, jmp, move, moves) // Walk the tree depth-first
+		// Each branch gets its own copy of the squares visited so far
+		branch := NewMove(player, move.Squares...)
+		branch.addSquare(jmp.land)
+
+		// A man reaching the far side carries on as a man, unless the
+		// variant crowns him there and then. In English draughts men can't
+		// capture backwards, so that ends the move.
+		crowned := king || v.PromoteDuringCapture && jmp.land.Y == v.crownRow(player)
+
+		piece := player
+		if crowned {
+			piece = -player
+		}
+
+		previous := b.Get(square)
+		b.Set(square, 0)
+		b.Set(jmp.land, piece)
 
-		// Now make a fresh move for when we follow the next branch
-		move = NewMove(player, square)
+		taken := append(captured[:len(captured):len(captured)], jmp.over)
+		b.jumpMoves(jmp.land, crowned, taken, branch, moves) // Walk the tree depth-first
+
+		b.Set(jmp.land, 0)
+		b.Set(square, previous)
 	}
 }
 
 // AllMoves returns a list of all valid moves for `player`
 func (b *Board) AllMoves(player int) []*Move {
+	if b.Variant().bitboardRules() {
+		return b.bb.Moves(player)
+	}
+	return b.arrayMoves(player)
+}
+
+// arrayMoves is the move generator scanning the array. It plays every
+// variant; for English draughts the bitboard generator is checked against
+// it.
+func (b *Board) arrayMoves(player int) []*Move {
+	v := b.Variant()
 	jumpMoves := []*Move{}
 	nonJumpMoves := []*Move{}
-	for y := 0; y < 8; y++ {
-		for x := 0; x < 8; x++ {
+	for y := 0; y < v.Size; y++ {
+		for x := 0; x < v.Size; x++ {
 			pos := Pos{x, y}
 			if abs(b.Get(pos)) == player {
 				jumpMoves = append(jumpMoves, b.JumpMoves(player, pos)...)
@@ -260,28 +537,51 @@ func (b *Board) AllMoves(player int) []*Move {
 	}
 
 	if len(jumpMoves) > 0 {
+		if v.MaximumCapture {
+			return longest(jumpMoves)
+		}
 		return jumpMoves
 	}
 
 	return nonJumpMoves
 }
 
-// CountPieces ...
+// longest picks the capture moves that take the most pieces. Every jump
+// takes a piece, so that's the moves visiting the most squares.
+func longest(moves []*Move) []*Move {
+	most := 0
+	for _, move := range moves {
+		if move.Length() > most {
+			most = move.Length()
+		}
+	}
+
+	list := []*Move{}
+	for _, move := range moves {
+		if move.Length() == most {
+			list = append(list, move)
+		}
+	}
+
+	return list
+}
+
+// CountPieces tallies up the men and kings held by each player
 func (b Board) CountPieces() *PieceCount {
 	pc := &PieceCount{}
 
-	for y := 0; y < 8; y++ {
-		for x := 0; x < 8; x++ {
+	for y := 0; y < b.Variant().Size; y++ {
+		for x := 0; x < b.Variant().Size; x++ {
 			switch b.Get(Pos{x, y}) {
 			case 2:
-				pc.men[1]++
+				pc.Men[1]++
 			case -2:
-				pc.kings[1]++
+				pc.Kings[1]++
 
 			case 1:
-				pc.men[0]++
+				pc.Men[0]++
 			case -1:
-				pc.kings[0]++
+				pc.Kings[0]++
 			default:
 			}
 		}
@@ -1,14 +1,17 @@
-package main
+package draughts
 
 import (
 	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
 	"testing"
 )
 
 func TestApply(t *testing.T) {
 	player := 1
 
-	board := &Board{[8][8]int{
+	board := NewBoardFromArray([8][8]int{
 		{0, 1, 0, 1, 0, 1, 0, 1},
 		{1, 0, 1, 0, 1, 0, 1, 0},
 		{0, 1, 0, 0, 0, 1, 0, 1},
@@ -17,9 +20,9 @@ func TestApply(t *testing.T) {
 		{2, 0, 2, 0, 0, 0, 2, 0},
 		{0, 2, 0, 2, 0, 2, 0, 2},
 		{2, 0, 2, 0, 2, 0, 2, 0},
-	}}
+	})
 
-	expected := &Board{[8][8]int{
+	expected := NewBoardFromArray([8][8]int{
 		{0, 1, 0, 1, 0, 1, 0, 1},
 		{1, 0, 1, 0, 1, 0, 1, 0},
 		{0, 1, 0, 0, 0, 1, 0, 1},
@@ -28,7 +31,7 @@ func TestApply(t *testing.T) {
 		{2, 0, 2, 0, 1, 0, 2, 0},
 		{0, 2, 0, 2, 0, 2, 0, 2},
 		{2, 0, 2, 0, 2, 0, 2, 0},
-	}}
+	})
 
 	move := NewMove(player, Pos{2, 3}, Pos{4, 5})
 
@@ -43,7 +46,7 @@ func TestApply(t *testing.T) {
 func TestValidate(t *testing.T) {
 	player := 1
 
-	board := &Board{[8][8]int{
+	board := NewBoardFromArray([8][8]int{
 		{0, 1, 0, 1, 0, 1, 0, 1},
 		{1, 0, 1, 0, 1, 0, 1, 0},
 		{0, 1, 0, 0, 0, 1, 0, 1},
@@ -52,7 +55,7 @@ func TestValidate(t *testing.T) {
 		{2, 0, 2, 0, 0, 0, 2, 0},
 		{0, 2, 0, 2, 0, 2, 0, 2},
 		{2, 0, 2, 0, 2, 0, 2, 0},
-	}}
+	})
 
 	// non-diagonal move
 	move := NewMove(player, Pos{5, 2}, Pos{5, 3})
@@ -68,7 +71,7 @@ func TestValidate(t *testing.T) {
 		t.Error("Validate reported illegal move 2 as legal")
 	}
 
-	board = &Board{[8][8]int{
+	board = NewBoardFromArray([8][8]int{
 		{0, 1, 0, 1, 0, 1, 0, 1},
 		{1, 0, 1, 0, 1, 0, 1, 0},
 		{0, 1, 0, 0, 0, 1, 0, 1},
@@ -77,7 +80,7 @@ func TestValidate(t *testing.T) {
 		{2, 0, 2, 0, 0, 0, 2, 0},
 		{0, 2, 0, 2, 0, 2, 0, 2},
 		{2, 0, 2, 0, 2, 0, 2, 0},
-	}}
+	})
 
 	// non-jump following jump
 	move = NewMove(player, Pos{3, 3}, Pos{1, 5}, Pos{0, 6})
@@ -86,7 +89,7 @@ func TestValidate(t *testing.T) {
 		t.Error("Validate reported illegal move 3 as legal")
 	}
 
-	board = &Board{[8][8]int{
+	board = NewBoardFromArray([8][8]int{
 		{0, 1, 0, 1, 0, 1, 0, 1},
 		{1, 0, 1, 0, 1, 0, 1, 0},
 		{0, 1, 0, 0, 0, 1, 0, 1},
@@ -95,7 +98,7 @@ func TestValidate(t *testing.T) {
 		{2, 2, 2, 0, 0, 0, 2, 0},
 		{0, 2, 0, 2, 0, 2, 0, 2},
 		{2, 0, 2, 0, 2, 0, 2, 0},
-	}}
+	})
 
 	// jump following non-jump
 	move = NewMove(player, Pos{3, 3}, Pos{2, 4}, Pos{0, 6})
@@ -122,7 +125,7 @@ func TestValidate(t *testing.T) {
 func TestValidateKing(t *testing.T) {
 	player := 2
 
-	board := &Board{[8][8]int{
+	board := NewBoardFromArray([8][8]int{
 		{0, 1, 0, 1, 0, 1, 0, 1},
 		{1, 0, 1, 0, 1, 0, 1, 0},
 		{0, 1, 0, 0, 0, 0, 0, 1},
@@ -131,7 +134,7 @@ func TestValidateKing(t *testing.T) {
 		{2, 0, 2, 0, 1, 0, 2, 0},
 		{0, 2, 0, 2, 0, 0, 0, 2},
 		{2, 0, 2, 0, 2, 0, 2, 0},
-	}}
+	})
 
 	move := NewMove(player, Pos{3, 4}, Pos{5, 6})
 	err := board.Validate(move)
@@ -155,7 +158,7 @@ func TestValidateKing(t *testing.T) {
 func TestAvailableJumps(t *testing.T) {
 	player := 1
 
-	board := &Board{[8][8]int{
+	board := NewBoardFromArray([8][8]int{
 		{0, 1, 0, 1, 0, 1, 0, 1},
 		{1, 0, 1, 0, 1, 0, 1, 0},
 		{0, 1, 0, 0, 0, 1, 0, 1},
@@ -164,7 +167,7 @@ func TestAvailableJumps(t *testing.T) {
 		{0, 0, 0, 0, 0, 0, 2, 0},
 		{0, 2, 0, 2, 0, 2, 0, 2},
 		{2, 0, 2, 0, 2, 0, 2, 0},
-	}}
+	})
 
 	jumps := board.singleJumps(player, Pos{2, 3})
 
@@ -176,7 +179,7 @@ func TestAvailableJumps(t *testing.T) {
 func TestAvailableJumpsKing(t *testing.T) {
 	player := 2
 
-	board := &Board{[8][8]int{
+	board := NewBoardFromArray([8][8]int{
 		{0, 1, 0, 1, 0, 1, 0, 1},
 		{1, 0, 1, 0, 1, 0, 1, 0},
 		{0, 0, 0, 0, 0, 0, 0, 1},
@@ -185,7 +188,7 @@ func TestAvailableJumpsKing(t *testing.T) {
 		{0, 0, 1, 0, 1, 0, 2, 0},
 		{0, 0, 0, 2, 0, 0, 0, 2},
 		{2, 0, 2, 0, 2, 0, 2, 0},
-	}}
+	})
 
 	jumps := board.singleJumps(player, Pos{3, 4})
 
@@ -197,7 +200,7 @@ func TestAvailableJumpsKing(t *testing.T) {
 func TestJumpMoves(t *testing.T) {
 	player := 1
 
-	board := &Board{[8][8]int{
+	board := NewBoardFromArray([8][8]int{
 		{0, 1, 0, 1, 0, 1, 0, 1},
 		{1, 0, 1, 0, 1, 0, 1, 0},
 		{0, 1, 0, 0, 0, 1, 0, 1},
@@ -206,7 +209,7 @@ func TestJumpMoves(t *testing.T) {
 		{0, 0, 0, 0, 0, 0, 2, 0},
 		{0, 2, 0, 2, 0, 2, 0, 2},
 		{2, 0, 0, 0, 2, 0, 2, 0},
-	}}
+	})
 
 	pos := Pos{2, 3}
 	movesList := board.JumpMoves(player, pos)
@@ -227,7 +230,7 @@ func TestJumpMoves(t *testing.T) {
 func TestNonCaptureMoves(t *testing.T) {
 	player := 1
 
-	board := &Board{[8][8]int{
+	board := NewBoardFromArray([8][8]int{
 		{0, 1, 0, 1, 0, 1, 0, 1},
 		{1, 0, 1, 0, 1, 0, 1, 0},
 		{0, 1, 0, 0, 0, 1, 0, 1},
@@ -236,7 +239,7 @@ func TestNonCaptureMoves(t *testing.T) {
 		{2, 0, 2, 0, 0, 0, 2, 0},
 		{0, 2, 0, 2, 0, 2, 0, 2},
 		{2, 0, 2, 0, 2, 0, 2, 0},
-	}}
+	})
 
 	movesList1 := board.nonCaptureMoves(player, Pos{5, 2})
 	if len(movesList1) != 2 {
@@ -259,7 +262,7 @@ func TestNonCaptureMoves(t *testing.T) {
 func TestAllMoves(t *testing.T) {
 	player := 1
 
-	board := &Board{[8][8]int{
+	board := NewBoardFromArray([8][8]int{
 		{0, 1, 0, 1, 0, 1, 0, 1},
 		{1, 0, 1, 0, 1, 0, 1, 0},
 		{0, 1, 0, 0, 0, 1, 0, 1},
@@ -268,7 +271,7 @@ func TestAllMoves(t *testing.T) {
 		{0, 0, 0, 0, 0, 0, 2, 0},
 		{0, 2, 0, 2, 0, 2, 0, 2},
 		{2, 0, 0, 0, 2, 0, 2, 0},
-	}}
+	})
 
 	// If jump moves are present only they count, so only 2
 	movesList := board.AllMoves(player)
@@ -281,7 +284,7 @@ func TestAllMoves(t *testing.T) {
 func TestAllMovesGeneratedAreValid(t *testing.T) {
 	player := 1
 
-	board := &Board{[8][8]int{
+	board := NewBoardFromArray([8][8]int{
 		{0, 1, 0, 1, 0, 1, 0, 1},
 		{1, 0, 1, 0, 1, 0, 1, 0},
 		{0, 1, 0, 0, 0, 1, 0, 1},
@@ -290,7 +293,7 @@ func TestAllMovesGeneratedAreValid(t *testing.T) {
 		{0, 0, 0, 0, 0, 0, 2, 0},
 		{0, 2, 0, 2, 0, 2, 0, 2},
 		{2, 0, 0, 0, 2, 0, 2, 0},
-	}}
+	})
 
 	for _, move := range board.AllMoves(player) {
 		err := board.Validate(move)
@@ -303,7 +306,7 @@ func TestAllMovesGeneratedAreValid(t *testing.T) {
 func TestContainsMove(t *testing.T) {
 	player := 2
 
-	board := &Board{[8][8]int{
+	board := NewBoardFromArray([8][8]int{
 		{0, 0, 0, 0, 0, 0, 0, 0},
 		{0, 0, 0, 0, -2, 0, 1, 0},
 		{0, -1, 0, 1, 0, 0, 0, 0},
@@ -312,9 +315,10 @@ func TestContainsMove(t *testing.T) {
 		{0, 0, 0, 0, 0, 0, 0, 0},
 		{0, 0, 0, 0, 0, 0, 0, 0},
 		{0, 0, 0, 0, -1, 0, 0, 0},
-	}}
+	})
 
-	move := NewMove(player, Pos{4, 1}, Pos{2, 3})
+	// The king changes direction after the first jump to take a second piece
+	move := NewMove(player, Pos{4, 1}, Pos{2, 3}, Pos{0, 1})
 	err := board.Validate(move)
 	if err != nil {
 		t.Errorf("Generated move %s reported invalid", move.AsString())
@@ -329,4 +333,198 @@ func TestContainsMove(t *testing.T) {
 			fmt.Printf("%s\n", m.AsString())
 		}
 	}
+
+	partial := NewMove(player, Pos{4, 1}, Pos{2, 3})
+	if ContainsMove(allMoves, partial) {
+		t.Errorf("Incomplete capture move %s reported complete", partial.AsString())
+	}
+}
+
+// boardFromSquares sets up a board from a map of PDN square to piece
+func boardFromSquares(pieces map[int]int) *Board {
+	board := &Board{}
+	for square, piece := range pieces {
+		board.Set(NewPosFromSquareID(square), piece)
+	}
+
+	return board
+}
+
+func TestRulesCompliance(t *testing.T) {
+	type rulesTest struct {
+		name     string
+		fen      string
+		expected []string // Every legal move, in any order
+		illegal  []string // Moves Validate must reject
+	}
+
+	var rulesTests = []rulesTest{
+		{
+			"capture is mandatory",
+			"B:W14:B1,9",
+			[]string{"9x18"},
+			[]string{"1-6"},
+		},
+		{
+			"men capture forwards only",
+			"B:W18,30:B22",
+			[]string{"22-25", "22-26"},
+			[]string{"22x15"},
+		},
+		{
+			"multi-jump branches share a prefix",
+			"B:W6,14,15:B1",
+			[]string{"1x10x17", "1x10x19"},
+			[]string{"1x10"},
+		},
+		{
+			"king may change direction between jumps",
+			"B:W18,19:BK22",
+			[]string{"22x15x24"},
+			[]string{"22x15"},
+		},
+		{
+			"king may return to its starting square, but not capture twice",
+			"B:W10,11,18,19:BK14",
+			[]string{"14x23x16x7x14", "14x7x16x23x14"},
+			[]string{"14x23x16x7x14x23", "14x23x16x7"},
+		},
+		{
+			"man crowned mid-capture ends the move",
+			"B:W26,27:B22",
+			[]string{"22x31"},
+			[]string{"22x31x24"},
+		},
+		{
+			"green man crowned mid-capture ends the move",
+			"W:W11:B6,7",
+			[]string{"11x2"},
+			[]string{"11x2x9"},
+		},
+	}
+
+	for _, tt := range rulesTests {
+		board, player, err := ParseFEN(English, tt.fen)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+
+		for name, moves := range map[string][]*Move{
+			"bitboard": board.AllMoves(player),
+			"array":    board.arrayMoves(player),
+		} {
+			actual := []string{}
+			for _, move := range moves {
+				actual = append(actual, board.MoveString(move))
+			}
+			sort.Strings(actual)
+
+			expected := append([]string{}, tt.expected...)
+			sort.Strings(expected)
+
+			if strings.Join(actual, " ") != strings.Join(expected, " ") {
+				t.Errorf("%s (%s generator): expected %v, found %v",
+					tt.name, name, expected, actual)
+			}
+		}
+
+		for _, moveStr := range tt.expected {
+			move, _ := ParseMove(strings.NewReplacer("x", " ", "-", " ").Replace(moveStr), player)
+			if err := board.Validate(move); err != nil {
+				t.Errorf("%s: legal move %s reported invalid (%s)", tt.name, moveStr, err)
+			}
+		}
+
+		allMoves := board.AllMoves(player)
+		for _, moveStr := range tt.illegal {
+			move, _ := ParseMove(strings.NewReplacer("x", " ", "-", " ").Replace(moveStr), player)
+			if CheckUserMove(board, allMoves, move) == nil {
+				t.Errorf("%s: illegal move %s reported valid", tt.name, moveStr)
+			}
+		}
+	}
+}
+
+func TestCrowning(t *testing.T) {
+	// A man reaching the far side is crowned
+	board := boardFromSquares(map[int]int{26: 1, 7: 2})
+	board = board.Apply(NewMove(1, NewPosFromSquareID(26), NewPosFromSquareID(31)))
+	if board.Get(NewPosFromSquareID(31)) != -1 {
+		t.Errorf("Expected red man crowned on 31, found %d",
+			board.Get(NewPosFromSquareID(31)))
+	}
+
+	board = board.Apply(NewMove(2, NewPosFromSquareID(7), NewPosFromSquareID(3)))
+	if board.Get(NewPosFromSquareID(3)) != -2 {
+		t.Errorf("Expected green man crowned on 3, found %d",
+			board.Get(NewPosFromSquareID(3)))
+	}
+
+	// ..but a king moving onto either baseline stays a king
+	board = board.Apply(NewMove(1, NewPosFromSquareID(31), NewPosFromSquareID(27)))
+	board = board.Apply(NewMove(1, NewPosFromSquareID(27), NewPosFromSquareID(32)))
+	if board.Get(NewPosFromSquareID(32)) != -1 {
+		t.Errorf("Expected red king on 32, found %d",
+			board.Get(NewPosFromSquareID(32)))
+	}
+}
+
+func TestMakeUnmakeMove(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	for _, v := range Variants {
+		for game := 0; game < 20; game++ {
+			board := NewVariantBoard(v)
+			player := v.FirstPlayer
+			for ply := 0; ply < 150; ply++ {
+				moves := board.AllMoves(player)
+				if len(moves) == 0 {
+					break
+				}
+
+				// Every move is made and unmade, and must agree with Apply
+				before := *board
+				for _, move := range moves {
+					undo := board.MakeMove(move)
+					if *board != *before.Apply(move) {
+						t.Fatalf("%s: MakeMove %s differs from Apply", v.Name, move.AsString())
+					}
+					board.UnmakeMove(undo)
+					if *board != before {
+						t.Fatalf("%s: UnmakeMove %s didn't restore the board", v.Name, move.AsString())
+					}
+				}
+
+				board.MakeMove(moves[rng.Intn(len(moves))])
+				player = Opposition(player)
+			}
+		}
+	}
+}
+
+// benchmarkPosition is a middle game position, with Red to move
+var benchmarkPosition = "B:W18,19,21,23,24,26,29,30,31,32:B1,2,3,5,6,7,9,10,12,14"
+
+// applied keeps the benchmarked boards, so they escape as they would in use
+var applied *Board
+
+func BenchmarkApply(b *testing.B) {
+	board, player, _ := ParseFEN(English, benchmarkPosition)
+	moves := board.AllMoves(player)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, move := range moves {
+			applied = board.Apply(move)
+		}
+	}
+}
+
+func BenchmarkMakeUnmakeMove(b *testing.B) {
+	board, player, _ := ParseFEN(English, benchmarkPosition)
+	moves := board.AllMoves(player)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, move := range moves {
+			board.UnmakeMove(board.MakeMove(move))
+		}
+	}
 }
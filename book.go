@@ -0,0 +1,268 @@
+package draughts
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+)
+
+// An opening book lists the moves known to be playable in the positions
+// of the opening, so that the engine needn't work them out from scratch,
+// and needn't play the same opening every game.
+//
+// Positions are keyed by their Zobrist hash, with the player to move, and
+// every move carries a weight: the more often it was played, and the better
+// it did, the higher. A book is written as a header naming the variant,
+// then one record per move, sorted by hash, each holding the hash, the
+// weight and the PDN squares the move visits.
+
+// bookMagic starts every opening book file
+const bookMagic = "DRBK\x01"
+
+// DefaultBookPlies is how deep into each game BuildBook goes unless told
+// otherwise
+const DefaultBookPlies = 20
+
+// BookMove is a move from an opening book, with its weight
+type BookMove struct {
+	Move   *Move
+	Weight int
+}
+
+// BookSelection is how Book.Choose picks between the moves for a position
+type BookSelection int
+
+const (
+	// BookWeighted picks at random, in proportion to the weights
+	BookWeighted BookSelection = iota
+
+	// BookBest always picks the move with the highest weight
+	BookBest
+)
+
+// Book is an opening book for one variant
+type Book struct {
+	Variant   *Variant
+	positions map[uint64][]BookMove
+}
+
+// NewBook is the constructor: an empty book for variant `v`
+func NewBook(v *Variant) *Book {
+	return &Book{Variant: v, positions: map[uint64][]BookMove{}}
+}
+
+// Len is the number of positions in the book
+func (bk *Book) Len() int {
+	return len(bk.positions)
+}
+
+// Add adds `weight` to that of `move` for `player` in position `b`,
+// adding the move if it isn't there yet
+func (bk *Book) Add(b *Board, player int, move *Move, weight int) {
+	key := b.Hash(player)
+	moves := bk.positions[key]
+	for i := range moves {
+		if moves[i].Move.Equals(move) {
+			moves[i].Weight += weight
+			return
+		}
+	}
+
+	bk.positions[key] = append(moves, BookMove{Move: move, Weight: weight})
+}
+
+// AddGame adds the first `plies` moves of a game to the book. Each move is
+// weighted by how the game went for the player who made it: 2 for a win, 1
+// for a draw or an unfinished game, and nothing for a loss, though the move
+// is still listed.
+func (bk *Book) AddGame(pdn *PDNGame, plies int) error {
+	v, err := pdn.Variant()
+	if err != nil {
+		return err
+	}
+	if v != bk.Variant {
+		return fmt.Errorf("Game is %s draughts, not %s", v.Name, bk.Variant.Name)
+	}
+
+	board, moves, err := pdn.Replay()
+	if err != nil {
+		return err
+	}
+
+	for ply, move := range moves {
+		if ply >= plies {
+			break
+		}
+
+		weight := 1
+		switch pdn.Result {
+		case WinResult(move.Player):
+			weight = 2
+		case WinResult(Opposition(move.Player)):
+			weight = 0
+		}
+
+		bk.Add(board, move.Player, move, weight)
+		board = board.Apply(move)
+	}
+
+	return nil
+}
+
+// BuildBook makes a book for variant `v` from the first `plies` moves of
+// each of `games`. Games of other variants are left out. Collections
+// usually have a few games that can't be replayed, with illegal moves or a
+// variant we don't know, and those are skipped too; it returns how many.
+func BuildBook(v *Variant, games []*PDNGame, plies int) (*Book, int) {
+	bk := NewBook(v)
+	skipped := 0
+	for _, pdn := range games {
+		gv, err := pdn.Variant()
+		if err != nil {
+			skipped++
+			continue
+		}
+		if gv != v {
+			continue
+		}
+
+		if err := bk.AddGame(pdn, plies); err != nil {
+			skipped++
+		}
+	}
+
+	return bk, skipped
+}
+
+// Moves returns the book moves for `player` in position `b`, best first,
+// as the board's own legal moves. Any that aren't legal there, as can
+// happen if two positions share a hash, are left out.
+func (bk *Book) Moves(b *Board, player int) []BookMove {
+	if bk == nil || b.Variant() != bk.Variant {
+		return nil
+	}
+
+	legal := b.AllMoves(player)
+	moves := []BookMove{}
+	for _, bm := range bk.positions[b.Hash(player)] {
+		for _, move := range legal {
+			if move.Equals(bm.Move) {
+				moves = append(moves, BookMove{Move: move, Weight: bm.Weight})
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(moves, func(i, j int) bool {
+		return moves[i].Weight > moves[j].Weight
+	})
+	return moves
+}
+
+// Choose picks a book move for `player` in position `b`, or returns nil if
+// the book has nothing to offer. Moves with no weight are never chosen.
+func (bk *Book) Choose(b *Board, player int, selection BookSelection, rng *rand.Rand) *Move {
+	moves := bk.Moves(b, player)
+	total := 0
+	for _, bm := range moves {
+		total += bm.Weight
+	}
+	if total == 0 {
+		return nil
+	}
+
+	if selection == BookBest {
+		return moves[0].Move
+	}
+
+	pick := rng.Intn(total)
+	for _, bm := range moves {
+		if pick < bm.Weight {
+			return bm.Move
+		}
+		pick -= bm.Weight
+	}
+
+	return nil
+}
+
+// Write saves the book
+func (bk *Book) Write(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	out.WriteString(bookMagic)
+
+	keys := make([]uint64, 0, len(bk.positions))
+	count := 0
+	for key, moves := range bk.positions {
+		keys = append(keys, key)
+		count += len(moves)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	binary.Write(out, binary.LittleEndian, [2]uint32{uint32(bk.Variant.GameType), uint32(count)})
+	for _, key := range keys {
+		for _, bm := range bk.positions[key] {
+			binary.Write(out, binary.LittleEndian, key)
+			binary.Write(out, binary.LittleEndian, uint32(bm.Weight))
+			out.WriteByte(byte(len(bm.Move.Squares)))
+			for _, p := range bm.Move.Squares {
+				out.WriteByte(byte(bk.Variant.SquareNumber(p)))
+			}
+		}
+	}
+
+	return out.Flush()
+}
+
+// ReadBook loads an opening book saved by Book.Write
+func ReadBook(r io.Reader) (*Book, error) {
+	in := bufio.NewReader(r)
+	magic := make([]byte, len(bookMagic))
+	if _, err := io.ReadFull(in, magic); err != nil || string(magic) != bookMagic {
+		return nil, fmt.Errorf("Not an opening book")
+	}
+
+	var header [2]uint32
+	if err := binary.Read(in, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("Truncated opening book: %s", err)
+	}
+	v, err := VariantByGameType(int(header[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	bk := NewBook(v)
+	for i := uint32(0); i < header[1]; i++ {
+		var record struct {
+			Key    uint64
+			Weight uint32
+			Length uint8
+		}
+		if err := binary.Read(in, binary.LittleEndian, &record); err != nil {
+			return nil, fmt.Errorf("Truncated opening book: %s", err)
+		}
+
+		squares := make([]byte, record.Length)
+		if _, err := io.ReadFull(in, squares); err != nil {
+			return nil, fmt.Errorf("Truncated opening book: %s", err)
+		}
+		if len(squares) < 2 {
+			return nil, fmt.Errorf("Opening book move with %d squares", len(squares))
+		}
+
+		move := &Move{}
+		for _, square := range squares {
+			if square < 1 || int(square) > v.SquareCount() {
+				return nil, fmt.Errorf("Opening book move to square %d", square)
+			}
+			move.Squares = append(move.Squares, v.SquarePos(int(square)))
+		}
+
+		bk.positions[record.Key] = append(bk.positions[record.Key], BookMove{Move: move, Weight: int(record.Weight)})
+	}
+
+	return bk, nil
+}
@@ -0,0 +1,152 @@
+package draughts
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+const bookPDN = `[Result "0-1"]
+1. 11-15 23-19 2. 8-11 22-17 0-1
+
+[Result "1-0"]
+1. 11-15 23-19 2. 9-13 22-18 1-0
+
+[Result "1/2-1/2"]
+1. 9-14 22-18 1/2-1/2
+
+[GameType "20"]
+1. 32-28 19-23 *
+`
+
+func testBook(t *testing.T) *Book {
+	games, err := ParsePDN(strings.NewReader(bookPDN))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	book, skipped := BuildBook(English, games, 3)
+	if skipped != 0 {
+		t.Fatalf("Expected every game to be used, %d were skipped", skipped)
+	}
+	return book
+}
+
+func TestBookBuild(t *testing.T) {
+	book := testBook(t)
+
+	// The start, after 11-15, after 11-15 23-19 and after 9-14
+	if book.Len() != 4 {
+		t.Errorf("Expected 4 positions, found %d", book.Len())
+	}
+
+	board := NewBoard()
+	moves := book.Moves(board, 1)
+	if len(moves) != 2 {
+		t.Fatalf("Expected 2 opening moves, found %d", len(moves))
+	}
+
+	// 11-15 won once and lost once; 9-14 drew
+	if board.MoveString(moves[0].Move) != "11-15" || moves[0].Weight != 2 || moves[1].Weight != 1 {
+		t.Errorf("Expected 11-15 with weight 2 first, found %s with %d",
+			board.MoveString(moves[0].Move), moves[0].Weight)
+	}
+
+	// Only three plies are taken from each game
+	board = board.Apply(moves[0].Move)
+	reply, _ := board.ResolveMove("23-19", 2)
+	board = board.Apply(reply)
+	next, _ := board.ResolveMove("8-11", 1)
+	if moves := book.Moves(board.Apply(next), 2); len(moves) != 0 {
+		t.Errorf("Expected nothing after the third ply, found %d moves", len(moves))
+	}
+}
+
+func TestBookSkipsBadGames(t *testing.T) {
+	// One good game, one with an illegal move and one of an unknown variant
+	games, err := ParsePDN(strings.NewReader(`[Result "0-1"]
+1. 11-15 23-19 0-1
+
+[Result "1-0"]
+1. 11-15 23-26 1-0
+
+[GameType "99"]
+1. 11-15 23-19 *
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	book, skipped := BuildBook(English, games, 3)
+	if skipped != 2 {
+		t.Errorf("Expected 2 games skipped, found %d", skipped)
+	}
+	if book.Len() != 2 {
+		t.Errorf("Expected the good game's 2 positions, found %d", book.Len())
+	}
+}
+
+func TestBookChoose(t *testing.T) {
+	book := testBook(t)
+	board := NewBoard()
+	rng := rand.New(rand.NewSource(1))
+
+	if move := book.Choose(board, 1, BookBest, rng); board.MoveString(move) != "11-15" {
+		t.Errorf("Expected the best move to be 11-15, found %s", board.MoveString(move))
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 3000; i++ {
+		counts[board.MoveString(book.Choose(board, 1, BookWeighted, rng))]++
+	}
+	if counts["11-15"] < 1800 || counts["11-15"] > 2200 || counts["9-14"]+counts["11-15"] != 3000 {
+		t.Errorf("Expected 11-15 two times in three, found %v", counts)
+	}
+
+	// After 11-15 23-19, 8-11 won and 9-13 lost, so only 8-11 is played
+	board = board.Apply(book.Choose(board, 1, BookBest, rng))
+	reply, _ := board.ResolveMove("23-19", 2)
+	board = board.Apply(reply)
+	for i := 0; i < 20; i++ {
+		if move := book.Choose(board, 1, BookWeighted, rng); board.MoveString(move) != "8-11" {
+			t.Fatalf("Expected 8-11, found %s", board.MoveString(move))
+		}
+	}
+
+	// Out of the book
+	if move := book.Choose(NewBoardFromArray([8][8]int{}), 1, BookWeighted, rng); move != nil {
+		t.Errorf("Expected no move for an empty board")
+	}
+	var none *Book
+	if move := none.Choose(NewBoard(), 1, BookBest, rng); move != nil {
+		t.Errorf("Expected no move without a book")
+	}
+}
+
+func TestBookReadWrite(t *testing.T) {
+	book := testBook(t)
+
+	var buf bytes.Buffer
+	if err := book.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	again, err := ReadBook(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if again.Variant != English || again.Len() != book.Len() {
+		t.Fatalf("Expected %d English positions, found %d %s", book.Len(), again.Len(), again.Variant.Name)
+	}
+	board := NewBoard()
+	if moves := again.Moves(board, 1); len(moves) != 2 || moves[0].Weight != 2 || moves[0].Move.Player != 1 {
+		t.Errorf("Expected the opening moves to survive, found %v", moves)
+	}
+
+	for _, bad := range [][]byte{nil, []byte("DRBK\x01"), buf.Bytes()[:len(buf.Bytes())-1]} {
+		if _, err := ReadBook(bytes.NewReader(bad)); err == nil {
+			t.Errorf("Expected an error reading %d bytes", len(bad))
+		}
+	}
+}
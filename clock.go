@@ -0,0 +1,180 @@
+package draughts
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMovesToGo is how many more moves we assume a player has to make
+// in the time they have left, when the time control doesn't say
+const DefaultMovesToGo = 30
+
+// TimeControl is how much time the players have for their moves. The usual
+// kinds are all combinations of these:
+//
+//   - sudden death: Time for the whole game
+//   - Fischer: Increment added after every move
+//   - Bronstein: up to Delay of the time each move took is given back
+//   - moves per period: Time again after every Moves moves
+type TimeControl struct {
+	Time      time.Duration
+	Increment time.Duration
+	Delay     time.Duration
+	Moves     int // 0 for a single period
+}
+
+// ParseTimeControl reads a time control written as minutes, optionally
+// preceded by a number of moves per period and followed by an increment or
+// delay in seconds: "5" is five minutes sudden death, "3+2" three minutes
+// with a two second increment, "5d3" five minutes with a three second
+// delay, and "40/90+30" 90 minutes for every 40 moves, with 30 seconds a
+// move.
+func ParseTimeControl(s string) (TimeControl, error) {
+	tc := TimeControl{}
+	text := s
+	if i := strings.Index(text, "/"); i >= 0 {
+		moves, err := strconv.Atoi(text[:i])
+		if err != nil || moves <= 0 {
+			return tc, fmt.Errorf("Bad moves per period in time control '%s'", s)
+		}
+		tc.Moves, text = moves, text[i+1:]
+	}
+
+	bonus := &tc.Increment
+	i := strings.IndexAny(text, "+d")
+	if i >= 0 {
+		if text[i] == 'd' {
+			bonus = &tc.Delay
+		}
+
+		seconds, err := strconv.ParseFloat(text[i+1:], 64)
+		if err != nil || seconds < 0 {
+			return tc, fmt.Errorf("Bad increment or delay in time control '%s'", s)
+		}
+		*bonus, text = time.Duration(seconds*float64(time.Second)), text[:i]
+	}
+
+	minutes, err := strconv.ParseFloat(text, 64)
+	if err != nil || minutes <= 0 {
+		return tc, fmt.Errorf("Bad time in time control '%s'", s)
+	}
+	tc.Time = time.Duration(minutes * float64(time.Minute))
+
+	return tc, nil
+}
+
+// String writes the time control as ParseTimeControl reads it
+func (tc TimeControl) String() string {
+	s := strconv.FormatFloat(tc.Time.Minutes(), 'f', -1, 64)
+	if tc.Moves > 0 {
+		s = fmt.Sprintf("%d/%s", tc.Moves, s)
+	}
+	if tc.Increment > 0 {
+		s += "+" + strconv.FormatFloat(tc.Increment.Seconds(), 'f', -1, 64)
+	}
+	if tc.Delay > 0 {
+		s += "d" + strconv.FormatFloat(tc.Delay.Seconds(), 'f', -1, 64)
+	}
+
+	return s
+}
+
+// MoveBudget shares `left` evenly between `movesToGo` moves, each of which
+// earns `bonus` more, though never more than half of what's left
+func MoveBudget(left, bonus time.Duration, movesToGo int) time.Duration {
+	return min(left/time.Duration(max(movesToGo, 1))+bonus, left/2)
+}
+
+// Clock is a game clock keeping to a TimeControl. Only the time of the
+// player to move runs, and a player whose time runs out has lost.
+type Clock struct {
+	Control TimeControl
+
+	remaining [2]time.Duration // As of `since`, for players 1 and 2
+	moves     [2]int           // Moves made by each player
+	running   int              // Player whose time is running, or 0
+	since     time.Time
+	now       func() time.Time
+}
+
+// NewClock is the constructor. The clock starts stopped.
+func NewClock(tc TimeControl) *Clock {
+	return &Clock{
+		Control:   tc,
+		remaining: [2]time.Duration{tc.Time, tc.Time},
+		now:       time.Now,
+	}
+}
+
+// Remaining returns the time `player` has left
+func (c *Clock) Remaining(player int) time.Duration {
+	left := c.remaining[player-1]
+	if c.running == player {
+		left -= c.now().Sub(c.since)
+	}
+
+	return max(left, 0)
+}
+
+// Flagged is true if `player` has run out of time
+func (c *Clock) Flagged(player int) bool {
+	return c.Remaining(player) == 0
+}
+
+// Running returns the player whose time is running, or 0 if neither's is
+func (c *Clock) Running() int {
+	return c.running
+}
+
+// Start runs `player`'s time, stopping their opponent's
+func (c *Clock) Start(player int) {
+	c.Stop()
+	c.running, c.since = player, c.now()
+}
+
+// Stop stops the time running
+func (c *Clock) Stop() {
+	if c.running != 0 {
+		c.remaining[c.running-1] = c.Remaining(c.running)
+		c.running = 0
+	}
+}
+
+// Press ends `player`'s move. Their time is stopped, anything the time
+// control gives them for the move is added, and their opponent's time
+// starts. If `player` has run out of time, nothing is added or started and
+// it returns false.
+func (c *Clock) Press(player int) bool {
+	taken := time.Duration(0)
+	if c.running == player {
+		taken = c.now().Sub(c.since)
+	}
+	c.Stop()
+
+	if c.Flagged(player) {
+		return false
+	}
+
+	c.moves[player-1]++
+	c.remaining[player-1] += c.Control.Increment + min(taken, c.Control.Delay)
+	if c.Control.Moves > 0 && c.moves[player-1]%c.Control.Moves == 0 {
+		c.remaining[player-1] += c.Control.Time
+	}
+
+	c.Start(Opposition(player))
+	return true
+}
+
+// Budget suggests how long `player` should think about their next move:
+// an even share of their time over the moves left to make in it, and
+// whatever they'll get back for the move
+func (c *Clock) Budget(player int) time.Duration {
+	movesToGo := DefaultMovesToGo
+	if c.Control.Moves > 0 {
+		movesToGo = c.Control.Moves - c.moves[player-1]%c.Control.Moves
+	}
+
+	return MoveBudget(c.Remaining(player), c.Control.Increment+c.Control.Delay, movesToGo)
+}
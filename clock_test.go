@@ -0,0 +1,117 @@
+package draughts
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeControl(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected TimeControl
+	}{
+		{"5", TimeControl{Time: 5 * time.Minute}},
+		{"3+2", TimeControl{Time: 3 * time.Minute, Increment: 2 * time.Second}},
+		{"5d3", TimeControl{Time: 5 * time.Minute, Delay: 3 * time.Second}},
+		{"40/90+30", TimeControl{Time: 90 * time.Minute, Increment: 30 * time.Second, Moves: 40}},
+		{"0.5+0.5", TimeControl{Time: 30 * time.Second, Increment: 500 * time.Millisecond}},
+	}
+
+	for _, test := range tests {
+		tc, err := ParseTimeControl(test.text)
+		if err != nil {
+			t.Errorf("%s: %s", test.text, err)
+			continue
+		}
+		if tc != test.expected {
+			t.Errorf("%s: expected %+v, found %+v", test.text, test.expected, tc)
+		}
+		if tc.String() != test.text {
+			t.Errorf("%s: written as %s", test.text, tc.String())
+		}
+	}
+
+	for _, text := range []string{"", "0", "-5", "five", "5+", "5+x", "x/5", "0/5", "5d-1"} {
+		if _, err := ParseTimeControl(text); err == nil {
+			t.Errorf("Expected an error parsing '%s'", text)
+		}
+	}
+}
+
+// testClock returns a clock whose time only passes when `advance` is called
+func testClock(tc TimeControl) (c *Clock, advance func(time.Duration)) {
+	now := time.Unix(0, 0)
+	c = NewClock(tc)
+	c.now = func() time.Time { return now }
+	return c, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestClockSuddenDeath(t *testing.T) {
+	c, advance := testClock(TimeControl{Time: time.Minute})
+
+	c.Start(1)
+	advance(20 * time.Second)
+	if c.Remaining(1) != 40*time.Second || c.Remaining(2) != time.Minute {
+		t.Errorf("Expected 40s and 60s, found %s and %s", c.Remaining(1), c.Remaining(2))
+	}
+
+	if !c.Press(1) || c.Running() != 2 {
+		t.Fatalf("Expected Green's time to be running")
+	}
+	advance(time.Minute + time.Second)
+	if !c.Flagged(2) || c.Remaining(2) != 0 {
+		t.Errorf("Expected Green to be out of time, found %s", c.Remaining(2))
+	}
+	if c.Press(2) {
+		t.Errorf("Expected a move out of time to be refused")
+	}
+	if c.Running() != 0 || c.Remaining(1) != 40*time.Second {
+		t.Errorf("Expected the clock to stop, found %d running", c.Running())
+	}
+}
+
+func TestClockIncrementAndDelay(t *testing.T) {
+	c, advance := testClock(TimeControl{Time: time.Minute, Increment: 5 * time.Second})
+	c.Start(1)
+	advance(2 * time.Second)
+	c.Press(1)
+	if c.Remaining(1) != 63*time.Second {
+		t.Errorf("Fischer: expected 63s, found %s", c.Remaining(1))
+	}
+
+	// Only as much time as was used, up to the delay, is given back
+	c, advance = testClock(TimeControl{Time: time.Minute, Delay: 5 * time.Second})
+	c.Start(1)
+	advance(2 * time.Second)
+	c.Press(1)
+	advance(8 * time.Second)
+	c.Press(2)
+	if c.Remaining(1) != time.Minute || c.Remaining(2) != 57*time.Second {
+		t.Errorf("Bronstein: expected 60s and 57s, found %s and %s", c.Remaining(1), c.Remaining(2))
+	}
+}
+
+func TestClockPeriods(t *testing.T) {
+	c, advance := testClock(TimeControl{Time: time.Minute, Moves: 2})
+	if c.Budget(1) != 30*time.Second {
+		t.Errorf("Expected half the time for the first of two moves, found %s", c.Budget(1))
+	}
+
+	for i := 0; i < 2; i++ {
+		c.Start(1)
+		advance(10 * time.Second)
+		c.Press(1)
+	}
+	if c.Remaining(1) != 100*time.Second {
+		t.Errorf("Expected the second period's time to be added, found %s", c.Remaining(1))
+	}
+}
+
+func TestMoveBudget(t *testing.T) {
+	if budget := MoveBudget(time.Minute, time.Second, 30); budget != 3*time.Second {
+		t.Errorf("Expected 3s, found %s", budget)
+	}
+	if budget := MoveBudget(4*time.Second, 10*time.Second, 30); budget != 2*time.Second {
+		t.Errorf("Expected no more than half the time left, found %s", budget)
+	}
+}
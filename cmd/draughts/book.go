@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/xpqz/draughts"
+)
+
+// BuildBook makes an opening book from PDN game collections and saves it,
+// as "draughts book [flags] games.pdn..."
+func BuildBook(args []string) error {
+	flags := flag.NewFlagSet("book", flag.ExitOnError)
+	variantName := flags.String("variant", "english", "take the games of this variant")
+	plies := flags.Int("plies", draughts.DefaultBookPlies, "take this many plies from the start of each game")
+	out := flags.String("out", "draughts.book", "save the book to this file")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		return fmt.Errorf("Give the PDN files to build the book from")
+	}
+
+	v, err := draughts.VariantByName(*variantName)
+	if err != nil {
+		return err
+	}
+
+	games, err := readGames(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	book, skipped := draughts.BuildBook(v, games, *plies)
+	fmt.Printf("%d positions from %d games, %d skipped\n", book.Len(), len(games), skipped)
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := book.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadBook reads an opening book saved by BuildBook, which must be for
+// variant `v`
+func LoadBook(filename string, v *draughts.Variant) (*draughts.Book, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	book, err := draughts.ReadBook(f)
+	if err != nil {
+		return nil, err
+	}
+	if book.Variant != v {
+		return nil, fmt.Errorf("%s is a book for %s draughts, not %s", filename, book.Variant.Name, v.Name)
+	}
+	return book, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xpqz/draughts"
+)
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func coloured(squareState, key int) {
+	padding := "  "
+	if key >= 10 {
+		padding = " "
+	}
+	king := " "
+	if squareState < 0 {
+		king = "*"
+	}
+	if abs(squareState) == 1 {
+		fmt.Printf("\033[31m%s%d%s \033[39m", padding, key, king) // RED
+	} else if abs(squareState) == 2 {
+		fmt.Printf("\033[32m%s%d%s \033[39m", padding, key, king) // GREEN
+	} else {
+		fmt.Printf("%s%d  ", padding, key)
+	}
+
+	fmt.Printf("|")
+}
+
+// clockString writes a player's time left as minutes and seconds, with
+// tenths in the last ten seconds
+func clockString(left time.Duration) string {
+	if left < 10*time.Second {
+		return fmt.Sprintf("0:%04.1f", left.Seconds())
+	}
+
+	seconds := int(left.Seconds())
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// Display prints out square numbers, coloured by player, and the time
+// each has left on `clock`, if there is one
+func Display(b *draughts.Board, clock *draughts.Clock) {
+	size := b.Variant().Size
+	border := "  +" + strings.Repeat("-----+", size) + "\n"
+
+	rCount, gCount := 0, 0
+	fmt.Print(border)
+	key := 1
+	for y := 0; y < size; y++ {
+		fmt.Print("  |")
+		for x := 0; x < size; x++ {
+			if y%2 == 0 && x%2 != 0 || // even y, odd x
+				y%2 != 0 && x%2 == 0 { // odd y, even x
+				squareState := b.Get(draughts.Pos{X: x, Y: y})
+				coloured(squareState, key)
+				if abs(squareState) == 1 {
+					rCount++
+				} else if abs(squareState) == 2 {
+					gCount++
+				}
+				key++
+			} else {
+				fmt.Print("     |")
+			}
+		}
+		fmt.Print("\n" + border)
+	}
+	fmt.Printf("  \033[31m[Red: %d]\033[39m \033[32m[Green: %d]\033[39m\n",
+		rCount, gCount)
+	if clock != nil {
+		fmt.Printf("  \033[31m[%s]\033[39m \033[32m[%s]\033[39m\n",
+			clockString(clock.Remaining(1)), clockString(clock.Remaining(2)))
+	}
+	// fmt.Printf("  \033[31m[Score: %d]\033[39m \033[32m[Score: %d]\033[39m\n",
+	// 	draughts.HeuristicValue(b, 1), draughts.HeuristicValue(b, 2))
+
+}
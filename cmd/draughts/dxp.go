@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/xpqz/draughts/protocol"
+)
+
+// PlayDXP plays international draughts against another program over DXP,
+// either waiting for it to connect on `listen`, or connecting to it at
+// `connect` and asking for a game with us as White
+func PlayDXP(listen, connect string, thinkTime time.Duration) error {
+	player := protocol.NewDXPPlayer("draughts")
+	player.Budget = thinkTime
+
+	if connect != "" {
+		conn, err := net.Dial("tcp", connect)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		return player.Initiate(conn, &protocol.DXPGameRequest{FollowerPlayer: 1})
+	}
+
+	ln, err := net.Listen("tcp", listen)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	fmt.Printf("Waiting for DXP games on %s\n", ln.Addr())
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+
+		// One opponent at a time; the engine's tables aren't shared
+		err = player.Follow(conn)
+		conn.Close()
+		fmt.Printf("Opponent left (%v)\n", err)
+	}
+}
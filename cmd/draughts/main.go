@@ -0,0 +1,483 @@
+// Command draughts is the interactive terminal game, either two humans
+// against each other or a human against the computer. "draughts serve"
+// serves games over HTTP instead, "draughts match" plays engines against each
+// other, "draughts tablebase" generates an endgame tablebase,
+// "draughts book" an opening book, "draughts perft" counts positions to
+// check move generation and "draughts tune" fits the evaluation weights.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xpqz/draughts"
+	"github.com/xpqz/draughts/protocol"
+)
+
+// errTimeout is returned by readLine when time runs out before a line does
+var errTimeout = fmt.Errorf("Out of time")
+
+// inputLine is a line of input, or the error that ended it
+type inputLine struct {
+	text string
+	err  error
+}
+
+// lines delivers the players' input, read in the background so that
+// waiting for it can be given up when a clock runs out
+var (
+	lines      = make(chan inputLine)
+	startInput sync.Once
+)
+
+// readLine returns the next line of input. Given a `timeout`, it gives up
+// after that long with errTimeout.
+func readLine(timeout time.Duration) (string, error) {
+	startInput.Do(func() {
+		go func() {
+			stdin := bufio.NewReader(os.Stdin)
+			for {
+				text, err := stdin.ReadString('\n')
+				lines <- inputLine{text, err}
+				for err != nil {
+					lines <- inputLine{"", err} // For good
+				}
+			}
+		}()
+	})
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		expired = time.After(timeout)
+	}
+
+	select {
+	case line := <-lines:
+		return strings.TrimSpace(line.text), line.err
+	case <-expired:
+		return "", errTimeout
+	}
+}
+
+// ReadMove creates a move from stdin input, numbering squares as for
+// variant `v`, waiting no longer than `timeout` if it's not 0. Instead of a
+// move, the player may enter "undo", "redo" or "draw" to offer a draw,
+// which is returned as `command`.
+func ReadMove(v *draughts.Variant, player int, timeout time.Duration) (move *draughts.Move, command string, err error) {
+	moveStr, err := readLine(timeout)
+	if err != nil {
+		return nil, "", err
+	}
+	if moveStr == "undo" || moveStr == "redo" || moveStr == "draw" {
+		return nil, moveStr, nil
+	}
+
+	move, err = v.ParseMove(moveStr, player)
+	return move, "", err
+}
+
+// takeBack undoes or redoes `count` moves, as asked for by `command`
+func takeBack(game *draughts.Game, command string, count int) {
+	for i := 0; i < count; i++ {
+		var err error
+		if command == "undo" {
+			err = game.Undo()
+		} else {
+			err = game.Redo()
+		}
+
+		if err != nil {
+			fmt.Printf("\n--> %s\n", err)
+			return
+		}
+	}
+}
+
+// startClock runs the clock, if there is one, for the player to move, and
+// returns how long they have left, or 0 if they have all the time in the
+// world
+func startClock(game *draughts.Game, clock *draughts.Clock) time.Duration {
+	if clock == nil {
+		return 0
+	}
+
+	if clock.Running() != game.Player() {
+		clock.Start(game.Player())
+	}
+	return clock.Remaining(game.Player())
+}
+
+// flagFell ends the game as a loss for the player to move if they've run
+// out of time
+func flagFell(game *draughts.Game, clock *draughts.Clock) bool {
+	if clock == nil || !clock.Flagged(game.Player()) {
+		return false
+	}
+
+	clock.Stop()
+	game.Forfeit(game.Player())
+	return true
+}
+
+// announceResult prints the result of a finished game
+func announceResult(game *draughts.Game) {
+	onTime := ""
+	if game.Forfeited() != 0 {
+		onTime = " on time"
+	}
+
+	switch game.Result() {
+	case draughts.ResultBlackWins:
+		fmt.Printf("Result: 0-1, \033[31mRed\033[39m wins%s\n", onTime)
+	case draughts.ResultWhiteWins:
+		fmt.Printf("Result: 1-0, \033[32mGreen\033[39m wins%s\n", onTime)
+	case draughts.ResultDraw:
+		fmt.Printf("Result: 1/2-1/2, drawn by %s\n", game.DrawReason())
+	}
+}
+
+// TwoPersonGame pits Human against Human, playing `game` out to the end,
+// on `clock` unless it's nil
+func TwoPersonGame(game *draughts.Game, clock *draughts.Clock) {
+	v := game.Board().Variant()
+	for !game.Over() {
+		Display(game.Board(), clock)
+
+		// Read-Validate-Apply move for the player to move
+		player := game.Player()
+		timeLeft := startClock(game, clock)
+		if player == 1 {
+			fmt.Printf("\033[31mRed's move: \033[39m")
+		} else {
+			fmt.Printf("\033[32mGreen's move: \033[39m")
+		}
+
+		move, command, err := ReadMove(v, player, timeLeft)
+		if err == io.EOF {
+			return // Abandoned
+		}
+		if flagFell(game, clock) {
+			fmt.Println()
+			break
+		}
+		if err != nil {
+			fmt.Printf("\n--> incorrectly entered move; try again (%s)\n",
+				err)
+			continue
+		}
+
+		if command == "draw" {
+			if player == 1 {
+				fmt.Printf("\033[32mGreen, Red offers a draw. Accept (y/n)? \033[39m")
+			} else {
+				fmt.Printf("\033[31mRed, Green offers a draw. Accept (y/n)? \033[39m")
+			}
+
+			answer, _ := readLine(0)
+			if answer == "y" {
+				game.AgreeDraw()
+			}
+			continue
+		}
+
+		if command != "" {
+			takeBack(game, command, 1)
+			continue
+		}
+
+		if err := game.Play(move); err != nil {
+			fmt.Printf("\n--> incorrect move; try again (%s)\n", err)
+		} else if clock != nil {
+			clock.Press(player)
+		}
+	}
+
+	if clock != nil {
+		clock.Stop()
+	}
+	Display(game.Board(), clock)
+	announceResult(game)
+}
+
+// Computer is the machine's side of a OnePersonGame: the engine it thinks
+// with, and the opening book it plays from for as long as it can
+type Computer struct {
+	Engine    *draughts.Engine
+	Book      *draughts.Book
+	Selection draughts.BookSelection
+	ShowStats bool // Note the search statistics with each move
+	rng       *rand.Rand
+}
+
+// NewComputer is the constructor, with no book
+func NewComputer(engine *draughts.Engine) *Computer {
+	return &Computer{Engine: engine, rng: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// Move picks `player`'s move on `board`: from the book if it has one, and
+// otherwise by searching for `budget`. It notes which it was, and with
+// ShowStats, how the search went.
+func (c *Computer) Move(board *draughts.Board, player int, budget time.Duration) (*draughts.Move, string) {
+	if move := c.Book.Choose(board, player, c.Selection, c.rng); move != nil {
+		return move, " (book)"
+	}
+
+	score := c.Engine.Search(board, player, draughts.SearchLimits{Budget: budget})
+	if c.ShowStats {
+		return score.Move, fmt.Sprintf(" (depth %d: %s)", score.Depth, score.Stats)
+	}
+	return score.Move, ""
+}
+
+// OnePersonGame pits Human vs Machine, playing `game` out to the end with
+// the machine as Red. On `clock`, if it's not nil, the machine shares out
+// its time between its moves, and otherwise thinks for `thinkTime` per
+// move. The search plays a sort of ok opening and middle game, but pretty
+// poor endgame, unless the engine has a tablebase to look endgames up in.
+func OnePersonGame(game *draughts.Game, computer *Computer, thinkTime time.Duration, clock *draughts.Clock) {
+	v := game.Board().Variant()
+	engine := computer.Engine
+	for !game.Over() {
+		board := game.Board()
+		Display(board, clock)
+		timeLeft := startClock(game, clock)
+
+		if game.Player() == 1 {
+			budget := thinkTime
+			if clock != nil {
+				budget = clock.Budget(1)
+			}
+
+			move, note := computer.Move(board, 1, budget)
+			if flagFell(game, clock) {
+				break
+			}
+			fmt.Printf("\033[31mRed's move: %s%s\n\033[39m", board.MoveString(move), note)
+			game.Play(move)
+			if clock != nil {
+				clock.Press(1)
+			}
+			continue
+		}
+
+		fmt.Printf("\033[32mGreen's move: \033[39m")
+		move, command, err := ReadMove(v, 2, timeLeft)
+		if err == io.EOF {
+			return // Abandoned
+		}
+		if flagFell(game, clock) {
+			fmt.Println()
+			break
+		}
+		if err != nil {
+			fmt.Printf("\n--> incorrectly entered move; try again (%s)\n",
+				err)
+			continue
+		}
+
+		// The computer takes a draw unless it thinks it's winning
+		if command == "draw" {
+			score := engine.Search(board, 2, draughts.SearchLimits{Budget: thinkTime})
+			if score.Value >= 0 {
+				fmt.Printf("\033[31mRed accepts the draw\n\033[39m")
+				game.AgreeDraw()
+			} else {
+				fmt.Printf("\033[31mRed declines the draw\n\033[39m")
+			}
+			continue
+		}
+
+		// Take back both the computer's move and our own, so it's our move
+		// again
+		if command != "" {
+			takeBack(game, command, 2)
+			continue
+		}
+
+		if err := game.Play(move); err != nil {
+			fmt.Printf("\n--> incorrect move; try again (%s)\n", err)
+		} else if clock != nil {
+			clock.Press(2)
+		}
+	}
+
+	if clock != nil {
+		clock.Stop()
+	}
+	Display(game.Board(), clock)
+	announceResult(game)
+}
+
+// subcommands are run as "draughts <name> [flags] ...", each taking its
+// own flags
+var subcommands = map[string]func([]string) error{
+	"serve":     Serve,
+	"tablebase": BuildTablebase,
+	"match":     PlayMatch,
+	"book":      BuildBook,
+	"perft":     RunPerft,
+	"tune":      TuneWeights,
+}
+
+// usage describes the flags for playing, and lists the subcommands
+func usage() {
+	names := []string{}
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags], or %s <command> -h for one of: %s\n",
+		os.Args[0], os.Args[0], strings.Join(names, ", "))
+	flag.PrintDefaults()
+}
+
+func main() {
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			if err := run(os.Args[2:]); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	flag.Usage = usage
+	thinkTime := flag.Int("think", 2000, "computer thinking time per move, in milliseconds")
+	variantName := flag.String("variant", "english",
+		"rules to play by: english, international, russian, brazilian or pool")
+	pdnFile := flag.String("pdn", "", "append the finished game to this PDN file")
+	replayFile := flag.String("replay", "", "step through the games in this PDN file")
+	fen := flag.String("fen", "", "start from this position, e.g. W:W21,22,K30:B1,2,3")
+	timeControl := flag.String("clock", "",
+		"play on a clock: minutes, plus an increment (5+3) or delay (5d3) in seconds, "+
+			"for a number of moves (40/90)")
+	engineMode := flag.Bool("engine", false, "speak the text engine protocol on stdin and stdout")
+	dxpListen := flag.String("dxp-listen", "", "wait for DXP games on this address, e.g. :27531")
+	dxpConnect := flag.String("dxp-connect", "", "play a DXP game against the program at this address")
+	tablebaseFile := flag.String("tablebase", "", "play endgames perfectly from this tablebase, see 'draughts tablebase'")
+	weightsFile := flag.String("weights", "", "evaluate positions with the weights in this JSON or TOML file")
+	bookFile := flag.String("book", "", "play openings from this book, see 'draughts book'")
+	bookBest := flag.Bool("book-best", false, "always play the book's best move, rather than one at random by weight")
+	threads := flag.Int("threads", runtime.NumCPU(), "search on this many goroutines at once")
+	showStats := flag.Bool("stats", false, "show how each of the computer's searches went")
+	flag.Parse()
+
+	if *engineMode {
+		if err := protocol.NewTextEngine(os.Stdout).Run(os.Stdin); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *dxpListen != "" || *dxpConnect != "" {
+		if err := PlayDXP(*dxpListen, *dxpConnect, time.Duration(*thinkTime)*time.Millisecond); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *replayFile != "" {
+		if err := ReplayGames(*replayFile); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	v, err := draughts.VariantByName(*variantName)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+
+	game := draughts.NewGame(v)
+	if *fen != "" {
+		board, player, err := draughts.ParseFEN(v, *fen)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+		game = draughts.NewGameFromPosition(board, player)
+	}
+
+	var clock *draughts.Clock
+	if *timeControl != "" {
+		tc, err := draughts.ParseTimeControl(*timeControl)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+		clock = draughts.NewClock(tc)
+	}
+
+	computer := NewComputer(draughts.NewEngine(draughts.DefaultTableSize))
+	computer.Engine.Threads = *threads
+	computer.ShowStats = *showStats
+	if *tablebaseFile != "" {
+		if computer.Engine.Tablebase, err = LoadTablebase(*tablebaseFile); err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+	}
+	if *weightsFile != "" {
+		data, err := os.ReadFile(*weightsFile)
+		if err == nil {
+			computer.Engine.Evaluator, err = draughts.ParseWeights(data)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+	}
+	if *bookFile != "" {
+		if computer.Book, err = LoadBook(*bookFile, v); err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+	}
+	if *bookBest {
+		computer.Selection = draughts.BookBest
+	}
+
+	fmt.Printf("Press c for computer opponent or anything else for human: ")
+	choice, _ := readLine(0)
+
+	if choice == "c" {
+		OnePersonGame(game, computer, time.Duration(*thinkTime)*time.Millisecond, clock)
+	} else {
+		TwoPersonGame(game, clock)
+	}
+
+	if *pdnFile != "" {
+		pdn := game.PDN()
+		if choice == "c" {
+			pdn.SetTag("Black", "Computer")
+			pdn.SetTag("White", "Human")
+		} else {
+			pdn.SetTag("Black", "Red")
+			pdn.SetTag("White", "Green")
+		}
+		pdn.SetTag("Date", time.Now().Format("2006.01.02"))
+		if clock != nil {
+			pdn.SetTag("TimeControl", clock.Control.String())
+		}
+		if err := SaveGame(*pdnFile, pdn); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/xpqz/draughts"
+	"github.com/xpqz/draughts/match"
+)
+
+// loadBallots returns the named set of ballots, three or eleven, or those
+// in the file `name`
+func loadBallots(name string) ([]draughts.Ballot, error) {
+	switch name {
+	case "three":
+		return draughts.ThreeMoveBallots()
+	case "eleven":
+		return draughts.ElevenManBallots()
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return draughts.ParseBallots(f)
+}
+
+// PlayMatch plays two engines against each other from tournament ballots,
+// each twice with the colours reversed, as "draughts match [flags]"
+func PlayMatch(args []string) error {
+	flags := flag.NewFlagSet("match", flag.ExitOnError)
+	specA := flags.String("a", "name=A", "the first engine: name=, depth=, time= in milliseconds, "+
+		"and cmd= with the command line of an external engine, last")
+	specB := flags.String("b", "name=B", "the second engine, as -a")
+	games := flags.Int("games", 20, "play this many games, two to a ballot")
+	ballotsName := flags.String("ballots", "three", "three, eleven, or a file of ballots, one a line")
+	concurrency := flags.Int("concurrency", runtime.NumCPU(), "play this many games at once")
+	maxPlies := flags.Int("max-plies", match.DefaultMaxPlies, "call a game drawn after this many plies")
+	sprt := flags.String("sprt", "", "stop once the first engine is shown to be no stronger than the first "+
+		"Elo difference, or at least the second, e.g. 0,10")
+	alpha := flags.Float64("alpha", 0.05, "the SPRT's chance of wrongly finding the first engine stronger")
+	beta := flags.Float64("beta", 0.05, "the SPRT's chance of wrongly finding it no stronger")
+	pdnFile := flags.String("pdn", "", "append the games to this PDN file")
+	flags.Parse(args)
+
+	m := &match.Match{Concurrency: *concurrency, MaxPlies: *maxPlies}
+	for i, spec := range []string{*specA, *specB} {
+		player, err := match.ParsePlayer(spec)
+		if err != nil {
+			return err
+		}
+		if closer, ok := player.(io.Closer); ok {
+			defer closer.Close()
+		}
+		m.Players[i] = player
+	}
+
+	if *sprt != "" {
+		var elo0, elo1 float64
+		if _, err := fmt.Sscanf(*sprt, "%g,%g", &elo0, &elo1); err != nil || elo1 <= elo0 {
+			return fmt.Errorf("Expected -sprt elo0,elo1, found '%s'", *sprt)
+		}
+		m.SPRT = &match.SPRT{Elo0: elo0, Elo1: elo1, Alpha: *alpha, Beta: *beta}
+	}
+
+	// Ballots are drawn without replacement, until they run out
+	ballots, err := loadBallots(*ballotsName)
+	if err != nil {
+		return err
+	}
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	rng.Shuffle(len(ballots), func(i, j int) { ballots[i], ballots[j] = ballots[j], ballots[i] })
+	for i := 0; i < (*games+1)/2; i++ {
+		m.Ballots = append(m.Ballots, ballots[i%len(ballots)])
+	}
+
+	names := [2]string{m.Players[0].Name(), m.Players[1].Name()}
+	_, stats, err := m.Run(func(r match.Result, stats match.Stats) {
+		fmt.Printf("%4d  %-24s %s v %s  %-7s  %s\n", stats.Games(), r.Ballot,
+			names[r.Red], names[1-r.Red], r.Game.Result(), stats)
+		if *pdnFile != "" {
+			if err := SaveGame(*pdnFile, m.PDN(r)); err != nil {
+				fmt.Println(err)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s v %s: %s\n", names[0], names[1], stats)
+	if m.SPRT != nil {
+		llr := m.SPRT.LLR(stats)
+		lower, upper := m.SPRT.Bounds()
+		verdict := map[int]string{
+			match.SPRTContinue: "undecided",
+			match.SPRTAcceptH0: fmt.Sprintf("H0 accepted, no better than %+g Elo", m.SPRT.Elo0),
+			match.SPRTAcceptH1: fmt.Sprintf("H1 accepted, at least %+g Elo", m.SPRT.Elo1),
+		}[m.SPRT.Test(stats)]
+		fmt.Printf("SPRT: LLR %.2f (%.2f, %.2f), %s\n", llr, lower, upper, verdict)
+	}
+	return nil
+}
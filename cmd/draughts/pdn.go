@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/xpqz/draughts"
+)
+
+// SaveGame appends `game` to the PDN file at `path`, creating it if needed
+func SaveGame(path string, game *draughts.PDNGame) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err == nil && info.Size() > 0 {
+		_, err = f.WriteString("\n")
+	}
+	if err == nil {
+		err = draughts.WritePDN(f, []*draughts.PDNGame{game})
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// readGames reads every game in the PDN files at `paths`
+func readGames(paths []string) ([]*draughts.PDNGame, error) {
+	games := []*draughts.PDNGame{}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		found, err := draughts.ParsePDN(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+		games = append(games, found...)
+	}
+
+	return games, nil
+}
+
+// ReplayGames shows the games in the PDN file at `path` move by move:
+// return steps forward a move, and b back a move
+func ReplayGames(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	games, err := draughts.ParsePDN(f)
+	if err != nil {
+		return err
+	}
+
+	for i, pdn := range games {
+		fmt.Printf("Game %d: %s - %s %s\n", i+1, pdn.Tag("Black"), pdn.Tag("White"), pdn.Result)
+
+		game, err := draughts.NewGameFromPDN(pdn)
+		if err != nil {
+			return fmt.Errorf("Game %d: %s", i+1, err)
+		}
+
+		// Rewind to the start, and step through
+		moves := game.Moves()
+		game.GoTo(0)
+		Display(game.Board(), nil)
+		for game.Ply() < len(moves) {
+			input, err := readLine(0)
+			if err != nil {
+				break
+			}
+
+			if input == "b" {
+				if game.Undo() == nil {
+					fmt.Printf("Back to ply %d\n", game.Ply())
+				}
+			} else {
+				move := moves[game.Ply()]
+				fmt.Printf("%s\n", game.Board().MoveString(move))
+				game.Redo()
+			}
+			Display(game.Board(), nil)
+		}
+	}
+
+	return nil
+}
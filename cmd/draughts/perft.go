@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/xpqz/draughts"
+)
+
+// RunPerft counts the positions a number of moves ahead, for checking the
+// move generator, as "draughts perft [flags]"
+func RunPerft(args []string) error {
+	flags := flag.NewFlagSet("perft", flag.ExitOnError)
+	variantName := flags.String("variant", "english", "rules to play by")
+	fen := flags.String("fen", "", "count from this position, rather than the start")
+	depth := flags.Int("depth", 6, "count this many plies ahead")
+	divide := flags.Bool("divide", false, "give the count after each first move")
+	flags.Parse(args)
+
+	v, err := draughts.VariantByName(*variantName)
+	if err != nil {
+		return err
+	}
+
+	board, player := draughts.NewVariantBoard(v), v.FirstPlayer
+	if *fen != "" {
+		if board, player, err = draughts.ParseFEN(v, *fen); err != nil {
+			return err
+		}
+	}
+
+	start := time.Now()
+	if *divide {
+		total := 0
+		for _, d := range draughts.Divide(board, player, *depth) {
+			fmt.Printf("%-12s %d\n", d.Move, d.Count)
+			total += d.Count
+		}
+		fmt.Printf("%-12s %d\n", "Total", total)
+	} else {
+		for d := 1; d <= *depth; d++ {
+			fmt.Printf("%2d %12d\n", d, draughts.Perft(board, player, d))
+		}
+	}
+	fmt.Printf("%s\n", time.Since(start).Round(time.Millisecond))
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/xpqz/draughts/server"
+)
+
+// Serve runs the HTTP game server, as "draughts serve [flags]"
+func Serve(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", ":8080", "address to listen on")
+	dir := flags.String("store", "", "keep games as PDN files in this directory, rather than in memory")
+	thinkTime := flags.Int("think", 2000, "engine thinking time per move, in milliseconds")
+	flags.Parse(args)
+
+	var store server.Store = server.NewMemoryStore()
+	if *dir != "" {
+		fileStore, err := server.NewFileStore(*dir)
+		if err != nil {
+			return err
+		}
+		store = fileStore
+	}
+
+	s := server.NewServer(store)
+	s.Budget = time.Duration(*thinkTime) * time.Millisecond
+
+	fmt.Printf("Serving games on %s\n", *addr)
+	return http.ListenAndServe(*addr, s)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/xpqz/draughts"
+)
+
+// BuildTablebase generates an endgame tablebase and saves it, as
+// "draughts tablebase [flags]"
+func BuildTablebase(args []string) error {
+	flags := flag.NewFlagSet("tablebase", flag.ExitOnError)
+	pieces := flags.Int("pieces", 6, "cover positions with up to this many pieces")
+	out := flags.String("out", "draughts.tb", "save the tablebase to this file")
+	flags.Parse(args)
+
+	start := time.Now()
+	tb, err := draughts.GenerateTablebase(*pieces, func(material string) {
+		fmt.Printf("%8s  %s\n", time.Since(start).Round(time.Second), material)
+	})
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := tb.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadTablebase reads a tablebase saved by BuildTablebase
+func LoadTablebase(filename string) (*draughts.Tablebase, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return draughts.ReadTablebase(f)
+}
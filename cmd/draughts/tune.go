@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/xpqz/draughts"
+)
+
+// TuneWeights fits the evaluation weights to the results of PDN game
+// collections and saves them, as "draughts tune [flags] games.pdn..."
+func TuneWeights(args []string) error {
+	flags := flag.NewFlagSet("tune", flag.ExitOnError)
+	weightsFile := flags.String("weights", "", "start from the weights in this file, rather than the defaults")
+	skip := flags.Int("skip", 8, "leave out this many plies from the start of each game")
+	passes := flags.Int("passes", 100, "stop after this many passes over the weights")
+	out := flags.String("out", "weights.toml", "save the weights to this file")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		return fmt.Errorf("Give the PDN files to tune with")
+	}
+
+	start := draughts.DefaultWeights
+	if *weightsFile != "" {
+		data, err := os.ReadFile(*weightsFile)
+		if err != nil {
+			return err
+		}
+		if start, err = draughts.ParseWeights(data); err != nil {
+			return fmt.Errorf("%s: %s", *weightsFile, err)
+		}
+	}
+
+	games, err := readGames(flags.Args())
+	if err != nil {
+		return err
+	}
+	positions, skipped := draughts.TuningPositions(games, *skip)
+	if len(positions) == 0 {
+		return fmt.Errorf("No positions to tune with: the games need results")
+	}
+	fmt.Printf("%d positions from %d games, %d skipped\n", len(positions), len(games), skipped)
+
+	weights, tuned, k := draughts.Tune(positions, start, *passes, func(pass int, w draughts.Weights, e float64) {
+		fmt.Printf("Pass %d: error %.6f %+v\n", pass, e, w)
+	})
+	fmt.Printf("K %.4f, error %.6f, from %.6f\n", k, tuned, draughts.TuningError(positions, start, k))
+
+	return os.WriteFile(*out, []byte(weights.TOML()), 0644)
+}
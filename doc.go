@@ -0,0 +1,8 @@
+// Package draughts implements the rules of English draughts (checkers),
+// and of the International, Russian, Brazilian and Pool variants: board
+// representation, move generation and validation, and an alpha-beta search
+// for a computer opponent.
+//
+// The interactive terminal game built on top of this package lives in
+// cmd/draughts.
+package draughts
@@ -0,0 +1,169 @@
+package draughts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Evaluator puts a number on a player's standing in a position the search
+// goes no deeper into: positive if they're ahead, negative if behind
+type Evaluator interface {
+	Evaluate(b *Board, player int) int
+}
+
+// Weights is the usual Evaluator: a polynomial in the differences between
+// the players' counts of pieces, kings, moves, pieces they can capture and
+// moves that crown, each multiplied by its weight
+type Weights struct {
+	Piece      int `json:"piece"`
+	King       int `json:"king"`
+	MovesCount int `json:"moves_count"`
+	Capturable int `json:"capturable"`
+	KingMaker  int `json:"king_maker"`
+}
+
+// DefaultWeights are the weights the engine plays with unless told
+// otherwise
+var DefaultWeights = Weights{
+	Piece:      15,
+	King:       20,
+	MovesCount: 2,
+	Capturable: 10,
+	KingMaker:  6,
+}
+
+type boardEval struct {
+	Player         int
+	PieceDiff      int
+	KingDiff       int
+	MovesCountDiff int
+	CapturableDiff int
+	KingMakerDiff  int
+}
+
+// KingsCaptures counts the moves in `movesList` which crown a man (or
+// land a king on the far side), and the pieces the captures take
+func KingsCaptures(b *Board, movesList []*Move) (int, int) {
+	kingMakers, capturable := 0, 0
+	for _, m := range movesList {
+		squares := len(m.Squares)
+		if m.Squares[squares-1].Y == b.Variant().crownRow(m.Player) {
+			kingMakers++
+		}
+		if b.IsCapture(m) {
+			capturable += squares - 1
+		}
+	}
+
+	return kingMakers, capturable
+}
+
+func eval(b *Board, player int) *boardEval {
+	allMoves := [2][]*Move{}
+	players := [2]int{1, 2}
+
+	pieceCount := b.CountPieces()
+
+	kingMakers := [2]int{}
+	capturable := [2]int{}
+
+	for i := 0; i < 2; i++ {
+		allMoves[i] = b.AllMoves(players[i])
+		kingMakers[i], capturable[i] = KingsCaptures(b, allMoves[i])
+	}
+
+	pieceDiff := (pieceCount.Men[player-1] + pieceCount.Kings[player-1]) -
+		(pieceCount.Men[Opposition(player)-1] + pieceCount.Kings[Opposition(player)-1])
+
+	kingDiff := pieceCount.Kings[player-1] - pieceCount.Kings[Opposition(player)-1]
+
+	movesCountDiff := len(allMoves[player-1]) - len(allMoves[Opposition(player)-1])
+
+	kingMakerDiff := kingMakers[player-1] - kingMakers[Opposition(player)-1]
+	capturableDiff := capturable[player-1] - capturable[Opposition(player)-1]
+
+	return &boardEval{
+		player,
+		pieceDiff,
+		kingDiff,
+		movesCountDiff,
+		capturableDiff,
+		kingMakerDiff,
+	}
+}
+
+// Evaluate is the weighted sum of the differences
+func (w Weights) Evaluate(b *Board, player int) int {
+	be := eval(b, player)
+
+	return be.PieceDiff*w.Piece +
+		be.KingDiff*w.King +
+		be.KingMakerDiff*w.KingMaker +
+		be.MovesCountDiff*w.MovesCount +
+		be.CapturableDiff*w.Capturable
+}
+
+// HeuristicValue puts a number on `player`'s standing, with the default
+// weights
+func HeuristicValue(b *Board, player int) int {
+	return DefaultWeights.Evaluate(b, player)
+}
+
+// ParseWeights reads weights from a JSON object or a TOML document, keyed
+// by the names in Weights's JSON tags. In TOML they may be at the top level
+// or in a [weights] table, so they can share a file with other settings:
+//
+//	[weights]
+//	piece = 15
+//	king = 20  # Kings count for more
+//
+// Any left out keep their default values.
+func ParseWeights(data []byte) (Weights, error) {
+	w := DefaultWeights
+	if text := bytes.TrimSpace(data); len(text) > 0 && text[0] == '{' {
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&w); err != nil {
+			return Weights{}, fmt.Errorf("Bad weights: %s", err)
+		}
+		return w, nil
+	}
+
+	doc, err := parseTOML(data)
+	if err != nil {
+		return Weights{}, fmt.Errorf("Bad weights: %s", err)
+	}
+	if table, ok := doc["weights"].(map[string]interface{}); ok && len(doc) == 1 {
+		doc = table
+	}
+
+	fields := map[string]*int{
+		"piece":       &w.Piece,
+		"king":        &w.King,
+		"moves_count": &w.MovesCount,
+		"capturable":  &w.Capturable,
+		"king_maker":  &w.KingMaker,
+	}
+	keys := []string{}
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field, known := fields[key]
+		if !known {
+			return Weights{}, fmt.Errorf("Unknown weight '%s'", key)
+		}
+
+		n, ok := doc[key].(int64)
+		if !ok || n != int64(int(n)) {
+			return Weights{}, fmt.Errorf("Weight %s must be a whole number, found %v", key, doc[key])
+		}
+		*field = int(n)
+	}
+
+	return w, nil
+}
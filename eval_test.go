@@ -0,0 +1,93 @@
+package draughts
+
+import (
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	player := 1
+
+	board := NewBoard()
+	be := eval(board, player)
+
+	if be.PieceDiff != 0 {
+		t.Errorf("Expected a piece difference of 0, found %d", be.PieceDiff)
+	}
+	if be.KingDiff != 0 {
+		t.Errorf("Expected a king difference of 0, found %d", be.KingDiff)
+	}
+
+	board = NewBoardFromArray([8][8]int{
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 1, 0, 1, 0},
+		{0, -1, 0, 1, 0, 0, 0, 0},
+		{0, 0, 0, 0, -1, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 2, 0, 0, 0, 0},
+	})
+
+	be = eval(board, player)
+
+	if be.PieceDiff != 4 {
+		t.Errorf("Expected a piece difference of 4, found %d", be.PieceDiff)
+	}
+	if be.KingDiff != 2 {
+		t.Errorf("Expected a king difference of 2, found %d", be.KingDiff)
+	}
+
+	board = NewBoardFromArray([8][8]int{
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, -1, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{2, 0, 0, 0, 0, 0, 0, 0},
+	})
+
+	be = eval(board, player)
+
+	if be.MovesCountDiff != 3 {
+		t.Errorf("Expected a movescount difference of 3, found %d in %+v",
+			be.MovesCountDiff, be)
+	}
+}
+
+func TestParseWeights(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected Weights
+	}{
+		{`{"piece": 100, "king_maker": 1}`, Weights{100, 20, 2, 10, 1}},
+		{"# A defensive personality\npiece = 30\n\nmoves_count = -1  # Sit tight\n", Weights{30, 20, -1, 10, 6}},
+		{"", DefaultWeights},
+		{"[weights]\n'king_maker' = 1_0\n", Weights{15, 20, 2, 10, 10}},
+	}
+	for _, test := range tests {
+		w, err := ParseWeights([]byte(test.text))
+		if err != nil {
+			t.Errorf("%q: %s", test.text, err)
+		} else if w != test.expected {
+			t.Errorf("%q: expected %+v, found %+v", test.text, test.expected, w)
+		}
+	}
+
+	for _, text := range []string{`{"queen": 9}`, `{"piece": "lots"}`, "piece: 15", "rook = 5", "piece = 1.5", "piece = \"lots\"",
+		"[weights]\npiece = 1\n[other]\nx = 1", "[weights]\npiece = 1\n[weights]"} {
+		if _, err := ParseWeights([]byte(text)); err == nil {
+			t.Errorf("Expected an error parsing %q", text)
+		}
+	}
+
+	// The default weights are the heuristic
+	board := NewBoard().Apply(NewBoard().AllMoves(1)[0])
+	if DefaultWeights.Evaluate(board, 2) != HeuristicValue(board, 2) {
+		t.Errorf("Expected the default weights to match HeuristicValue")
+	}
+	if (Weights{Piece: 1}).Evaluate(bitboardBoard(Bitboard{Red: 3, Green: 1 << 31}), 1) != 1 {
+		t.Errorf("Expected one piece ahead to be worth 1")
+	}
+}
@@ -0,0 +1,127 @@
+package draughts
+
+// PDN-FEN describes a position as text: the side to move, then the squares
+// of each side's pieces, with kings marked K. For example
+//
+//	W:W21,22,K30:B1,2,3
+//
+// is White to move, with men on 21 and 22 and a king on 30, against Black's
+// men on 1, 2 and 3. A run of squares may be given as a range, like 1-12.
+// As in PDN, White is player 2, at the bottom of the board, and Black is
+// player 1.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// fenColours are the FEN letters for each player, indexed by player-1
+var fenColours = [2]string{"B", "W"}
+
+// fenPlayer returns the player for FEN colour letter `colour`
+func fenPlayer(colour string) (int, error) {
+	for i, c := range fenColours {
+		if strings.EqualFold(colour, c) {
+			return i + 1, nil
+		}
+	}
+
+	return 0, fmt.Errorf("Bad colour '%s'", colour)
+}
+
+// ParseFEN sets up a board for variant `v` from the position `fen`, and
+// returns it along with the player to move
+func ParseFEN(v *Variant, fen string) (*Board, int, error) {
+	fields := strings.Split(strings.TrimSuffix(strings.TrimSpace(fen), "."), ":")
+	if len(fields) < 1 || len(fields[0]) != 1 {
+		return nil, 0, fmt.Errorf("FEN must start with the side to move")
+	}
+
+	player, err := fenPlayer(fields[0])
+	if err != nil {
+		return nil, 0, err
+	}
+
+	board := &Board{variant: v}
+	for _, field := range fields[1:] {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		owner, err := fenPlayer(field[:1])
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if err := board.setFENPieces(owner, field[1:]); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return board, player, nil
+}
+
+// setFENPieces places `player`'s pieces from a comma separated list of
+// squares and ranges
+func (b *Board) setFENPieces(player int, list string) error {
+	v := b.Variant()
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		piece := player
+		if entry[0] == 'K' || entry[0] == 'k' {
+			piece = -player
+			entry = entry[1:]
+		}
+
+		bounds := strings.SplitN(entry, "-", 2)
+		first, err := strconv.Atoi(bounds[0])
+		last := first
+		if err == nil && len(bounds) == 2 {
+			last, err = strconv.Atoi(bounds[1])
+		}
+		if err != nil || first < 1 || last > v.SquareCount() || first > last {
+			return fmt.Errorf("Bad square '%s'", entry)
+		}
+
+		for square := first; square <= last; square++ {
+			p := v.SquarePos(square)
+			if b.Get(p) != 0 {
+				return fmt.Errorf("Square %d given twice", square)
+			}
+			b.Set(p, piece)
+		}
+	}
+
+	return nil
+}
+
+// FEN describes the position with `player` to move. Squares are listed in
+// order, White's pieces first.
+func (b Board) FEN(player int) string {
+	v := b.Variant()
+	var sb strings.Builder
+	sb.WriteString(fenColours[player-1])
+
+	for _, owner := range []int{2, 1} {
+		sb.WriteString(":" + fenColours[owner-1])
+
+		squares := []string{}
+		for square := 1; square <= v.SquareCount(); square++ {
+			piece := b.Get(v.SquarePos(square))
+			if piece == owner {
+				squares = append(squares, strconv.Itoa(square))
+			} else if piece == -owner {
+				squares = append(squares, "K"+strconv.Itoa(square))
+			}
+		}
+		sb.WriteString(strings.Join(squares, ","))
+	}
+
+	return sb.String()
+}
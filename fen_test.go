@@ -0,0 +1,125 @@
+package draughts
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestParseFEN(t *testing.T) {
+	board, player, err := ParseFEN(English, "W:W21,22,K30:B1,2,3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if player != 2 {
+		t.Errorf("Expected player 2 to move, found %d", player)
+	}
+
+	expected := map[int]int{21: 2, 22: 2, 30: -2, 1: 1, 2: 1, 3: 1}
+	for square := 1; square <= 32; square++ {
+		if piece := board.Get(English.SquarePos(square)); piece != expected[square] {
+			t.Errorf("Square %d: expected %d, found %d", square, expected[square], piece)
+		}
+	}
+
+	// Ranges, lower case and a trailing full stop are all allowed
+	board, player, err = ParseFEN(English, "b:w21-32:bK1-12.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pc := board.CountPieces(); player != 1 || pc.Men[1] != 12 || pc.Kings[0] != 12 {
+		t.Errorf("Range not read correctly: %v", pc)
+	}
+}
+
+func TestParseFENErrors(t *testing.T) {
+	bad := []string{
+		"",
+		"X:W21:B1",
+		"W:W21:X1",
+		"W:W33:B1",
+		"W:W0:B1",
+		"W:W21:B21",
+		"W:W12-1:B30",
+		"W:Wtwo:B1",
+	}
+
+	for _, fen := range bad {
+		if _, _, err := ParseFEN(English, fen); err == nil {
+			t.Errorf("Expected an error parsing '%s'", fen)
+		}
+	}
+}
+
+func TestFEN(t *testing.T) {
+	if fen := NewBoard().FEN(1); fen != "B:W21,22,23,24,25,26,27,28,29,30,31,32:B1,2,3,4,5,6,7,8,9,10,11,12" {
+		t.Errorf("Unexpected initial position %s", fen)
+	}
+
+	// Every variant's initial position, and random English positions, make
+	// the round trip
+	for _, v := range Variants {
+		board := NewVariantBoard(v)
+		again, player, err := ParseFEN(v, board.FEN(v.FirstPlayer))
+		if err != nil || player != v.FirstPlayer || again.Hash(player) != board.Hash(v.FirstPlayer) {
+			t.Errorf("%s: initial position doesn't round trip via %s", v.Name, board.FEN(v.FirstPlayer))
+		}
+	}
+
+	rng := rand.New(rand.NewSource(4))
+	for i := 0; i < 100; i++ {
+		board := randomBoard(rng)
+		again, _, err := ParseFEN(English, board.FEN(2))
+		if err != nil || again.state != board.state {
+			t.Fatalf("Position doesn't round trip via %s", board.FEN(2))
+		}
+	}
+}
+
+func TestPDNGameFromPosition(t *testing.T) {
+	start, player, err := ParseFEN(English, "W:W18,K30:B1,14")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	moves := []*Move{}
+	for _, text := range []string{"18x9", "1-5", "9-6"} {
+		move, err := start.ResolveMove(text, player)
+		if err != nil {
+			t.Fatalf("%s: %s", text, err)
+		}
+		moves = append(moves, move)
+		start = start.Apply(move)
+		player = Opposition(player)
+	}
+
+	start, player, _ = ParseFEN(English, "W:W18,K30:B1,14")
+	g := NewPDNGame(start, player, moves)
+	if g.Tag("FEN") != "W:W18,K30:B1,14" {
+		t.Errorf("Expected a FEN tag, found '%s'", g.Tag("FEN"))
+	}
+
+	// White moving first starts at 1...
+	if !strings.Contains(g.String(), "1... 18x9 2. 1-5 9-6") {
+		t.Errorf("Unexpected move numbers:\n%s", g)
+	}
+
+	games, err := ParsePDN(strings.NewReader(g.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replayedStart, replayed, err := games[0].Replay()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayedStart.FEN(2) != start.FEN(2) || len(replayed) != 3 {
+		t.Errorf("Game didn't replay from its FEN position")
+	}
+
+	// Games from the initial position don't need one
+	if g := NewPDNGame(NewBoard(), 1, nil); g.Tag("FEN") != "" {
+		t.Errorf("Unexpected FEN tag for the initial position")
+	}
+}
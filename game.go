@@ -0,0 +1,313 @@
+package draughts
+
+import "fmt"
+
+// Reasons for a draw
+const (
+	DrawRepetition = "threefold repetition"
+	DrawQuietMoves = "quiet move rule"
+	DrawEndgame    = "endgame move rule"
+	DrawAgreed     = "agreement"
+)
+
+// Game is a game in progress, or finished: the position it started from,
+// the moves played since, and the position after each. Moves can be taken
+// back with Undo, and replayed with Redo until a different move is played.
+type Game struct {
+	startPlayer int
+	moves       []*Move  // Every move, including those undone
+	boards      []*Board // boards[i] is the position after i moves
+	ply         int      // Number of moves currently played
+	drawAgreed  bool     // In the current position
+	forfeited   int      // Player who lost on time, in the current position
+}
+
+// NewGame starts a game of variant `v` from the initial position
+func NewGame(v *Variant) *Game {
+	return NewGameFromPosition(NewVariantBoard(v), v.FirstPlayer)
+}
+
+// NewGameFromPosition starts a game from `board`, with `player` to move
+func NewGameFromPosition(board *Board, player int) *Game {
+	return &Game{startPlayer: player, boards: []*Board{board}}
+}
+
+// NewGameFromPDN replays the main line of a PDN game. A game recorded as
+// drawn, which the rules don't say is, must have been agreed drawn, and one
+// recorded as won must have been forfeited by the loser.
+func NewGameFromPDN(pdn *PDNGame) (*Game, error) {
+	start, moves, err := pdn.Replay()
+	if err != nil {
+		return nil, err
+	}
+
+	_, player, _ := pdn.Start()
+	g := NewGameFromPosition(start, player)
+	for _, move := range moves {
+		g.play(move)
+	}
+
+	if !g.Over() {
+		switch pdn.Result {
+		case ResultDraw:
+			g.drawAgreed = true
+		case ResultWhiteWins:
+			g.forfeited = 1
+		case ResultBlackWins:
+			g.forfeited = 2
+		}
+	}
+
+	return g, nil
+}
+
+// Start returns the position the game started from, and the player to move
+func (g *Game) Start() (*Board, int) {
+	return g.boards[0], g.startPlayer
+}
+
+// Board returns the current position
+func (g *Game) Board() *Board {
+	return g.boards[g.ply]
+}
+
+// Player returns the player to move
+func (g *Game) Player() int {
+	return g.PlayerAt(g.ply)
+}
+
+// PlayerAt returns the player to move after `ply` moves
+func (g *Game) PlayerAt(ply int) int {
+	if ply%2 == 0 {
+		return g.startPlayer
+	}
+	return Opposition(g.startPlayer)
+}
+
+// Ply returns the number of moves played
+func (g *Game) Ply() int {
+	return g.ply
+}
+
+// Moves returns the moves played, in order
+func (g *Game) Moves() []*Move {
+	return g.moves[:g.ply]
+}
+
+// BoardAt returns the position after `ply` moves. Positions undone, but
+// still available to Redo, count.
+func (g *Game) BoardAt(ply int) (*Board, error) {
+	if ply < 0 || ply >= len(g.boards) {
+		return nil, fmt.Errorf("No position at ply %d", ply)
+	}
+
+	return g.boards[ply], nil
+}
+
+// LegalMoves returns the moves available to the player to move
+func (g *Game) LegalMoves() []*Move {
+	return g.Board().AllMoves(g.Player())
+}
+
+// Over is true if the game has been won or drawn
+func (g *Game) Over() bool {
+	return g.Result() != ResultUnknown
+}
+
+// Result returns the PDN result of the game: a win for the player's
+// opponent if the player to move can't move or a player has forfeited, a
+// draw if DrawReason says so, and ResultUnknown until then
+func (g *Game) Result() string {
+	if g.forfeited != 0 {
+		return WinResult(Opposition(g.forfeited))
+	}
+
+	if len(g.LegalMoves()) == 0 {
+		return WinResult(Opposition(g.Player()))
+	}
+
+	if g.DrawReason() != "" {
+		return ResultDraw
+	}
+
+	return ResultUnknown
+}
+
+// DrawReason says why the game is drawn, or returns "" if it isn't
+func (g *Game) DrawReason() string {
+	switch {
+	case g.drawAgreed:
+		return DrawAgreed
+	case g.Repetitions() >= 3:
+		return DrawRepetition
+	case g.QuietPlies() >= 2*g.Board().Variant().QuietMoves:
+		return DrawQuietMoves
+	}
+
+	if plies, limit := g.endgamePlies(); limit > 0 && plies >= limit {
+		return DrawEndgame
+	}
+
+	return ""
+}
+
+// AgreeDraw ends the game in a draw, as agreed by the players
+func (g *Game) AgreeDraw() error {
+	if g.Over() {
+		return fmt.Errorf("Game is over")
+	}
+
+	g.drawAgreed = true
+	return nil
+}
+
+// Forfeit ends the game as a loss for `player`, as when their time runs out
+func (g *Game) Forfeit(player int) error {
+	if g.Over() {
+		return fmt.Errorf("Game is over")
+	}
+
+	g.forfeited = player
+	return nil
+}
+
+// Forfeited returns the player who forfeited the game, or 0 if neither has
+func (g *Game) Forfeited() int {
+	return g.forfeited
+}
+
+// QuietPlies counts the moves since the last capture or move by a man,
+// neither of which can be undone, so no position before them can repeat
+func (g *Game) QuietPlies() int {
+	quiet := 0
+	for ply := g.ply; ply > 0; ply-- {
+		board, move := g.boards[ply-1], g.moves[ply-1]
+		if board.Get(move.Squares[0]) > 0 || board.IsCapture(move) {
+			break
+		}
+		quiet++
+	}
+
+	return quiet
+}
+
+// endgamePlyLimit is how many plies the pieces against a lone king get to
+// win in, or 0 if the position isn't one of the short endgames
+func endgamePlyLimit(b *Board) int {
+	if !b.Variant().ShortEndgames {
+		return 0
+	}
+
+	count := b.CountPieces()
+	for player := 1; player <= 2; player++ {
+		other := Opposition(player) - 1
+		pieces := count.Men[other] + count.Kings[other]
+		if count.Men[player-1] != 0 || count.Kings[player-1] != 1 || count.Kings[other] == 0 || pieces > 3 {
+			continue
+		}
+
+		if pieces == 3 {
+			return 2 * 16
+		}
+		return 2 * 5
+	}
+
+	return 0
+}
+
+// endgamePlies counts the moves since the game came down to one of the
+// short endgames, and returns them with the endgame's limit
+func (g *Game) endgamePlies() (int, int) {
+	limit := endgamePlyLimit(g.Board())
+	if limit == 0 {
+		return 0, 0
+	}
+
+	plies := 0
+	for ply := g.ply; ply > 0 && endgamePlyLimit(g.boards[ply-1]) == limit; ply-- {
+		plies++
+	}
+	return plies, limit
+}
+
+// Repetitions counts how many times the current position has occurred,
+// with the same player to move, including now
+func (g *Game) Repetitions() int {
+	hash := g.Board().Hash(g.Player())
+	count := 1
+	for ply := g.ply - 2; ply >= g.ply-g.QuietPlies(); ply -= 2 {
+		if g.boards[ply].Hash(g.PlayerAt(ply)) == hash {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Play makes `move` for the player to move, if it's legal. Any moves
+// undone can no longer be redone.
+func (g *Game) Play(move *Move) error {
+	if move.Player != g.Player() {
+		return fmt.Errorf("It's player %d's move", g.Player())
+	}
+
+	if g.Over() {
+		return fmt.Errorf("Game is over")
+	}
+
+	if err := CheckUserMove(g.Board(), g.LegalMoves(), move); err != nil {
+		return err
+	}
+
+	g.play(move)
+	return nil
+}
+
+// play makes a move known to be legal
+func (g *Game) play(move *Move) {
+	g.moves = append(g.moves[:g.ply], move)
+	g.boards = append(g.boards[:g.ply+1], g.Board().Apply(move))
+	g.ply++
+	g.drawAgreed, g.forfeited = false, 0
+}
+
+// Undo takes back the last move
+func (g *Game) Undo() error {
+	if g.ply == 0 {
+		return fmt.Errorf("No moves to undo")
+	}
+
+	g.ply--
+	g.drawAgreed, g.forfeited = false, 0
+	return nil
+}
+
+// Redo replays the last move undone
+func (g *Game) Redo() error {
+	if g.ply == len(g.moves) {
+		return fmt.Errorf("No moves to redo")
+	}
+
+	g.ply++
+	g.drawAgreed, g.forfeited = false, 0
+	return nil
+}
+
+// GoTo undoes or redoes moves until `ply` moves have been played
+func (g *Game) GoTo(ply int) error {
+	if ply < 0 || ply > len(g.moves) {
+		return fmt.Errorf("No position at ply %d", ply)
+	}
+
+	g.ply = ply
+	g.drawAgreed, g.forfeited = false, 0
+	return nil
+}
+
+// PDN returns the record of the moves played
+func (g *Game) PDN() *PDNGame {
+	start, player := g.Start()
+	pdn := NewPDNGame(start, player, g.Moves())
+	pdn.SetTag("Result", g.Result())
+	return pdn
+}
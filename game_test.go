@@ -0,0 +1,359 @@
+package draughts
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// playMoves plays moves given in PDN, failing the test if any is illegal
+func playMoves(t *testing.T, g *Game, texts ...string) {
+	for _, text := range texts {
+		move, err := g.Board().ResolveMove(text, g.Player())
+		if err == nil {
+			err = g.Play(move)
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", text, err)
+		}
+	}
+}
+
+func TestGameUndoRedo(t *testing.T) {
+	g := NewGame(English)
+	playMoves(t, g, "11-15", "23-19", "8-11")
+
+	if g.Ply() != 3 || g.Player() != 2 {
+		t.Fatalf("Expected ply 3 with player 2 to move, found %d, %d", g.Ply(), g.Player())
+	}
+	afterThree := g.Board().FEN(g.Player())
+
+	if err := g.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if g.Ply() != 2 || g.Player() != 1 || len(g.Moves()) != 2 {
+		t.Errorf("Undo didn't take back a move")
+	}
+
+	if err := g.Redo(); err != nil {
+		t.Fatal(err)
+	}
+	if g.Board().FEN(g.Player()) != afterThree {
+		t.Errorf("Redo didn't restore the position")
+	}
+
+	if err := g.Redo(); err == nil {
+		t.Errorf("Expected nothing to redo")
+	}
+
+	// Playing a different move after an undo drops the old line
+	g.Undo()
+	playMoves(t, g, "9-14")
+	if err := g.Redo(); err == nil {
+		t.Errorf("Expected the undone move to be forgotten")
+	}
+	if g.Board().FEN(g.Player()) == afterThree {
+		t.Errorf("Expected a different position")
+	}
+
+	for g.Ply() > 0 {
+		g.Undo()
+	}
+	if err := g.Undo(); err == nil {
+		t.Errorf("Expected nothing to undo")
+	}
+	if g.Board().FEN(1) != NewBoard().FEN(1) {
+		t.Errorf("Expected the initial position after undoing everything")
+	}
+}
+
+func TestGameGoTo(t *testing.T) {
+	g := NewGame(English)
+	playMoves(t, g, "11-15", "23-19", "8-11", "22-17")
+
+	if err := g.GoTo(1); err != nil {
+		t.Fatal(err)
+	}
+	if g.Ply() != 1 || g.Player() != 2 {
+		t.Errorf("Expected ply 1 with player 2 to move")
+	}
+
+	// Every position is still there to look at, and to go back to
+	for ply := 0; ply <= 4; ply++ {
+		board, err := g.BoardAt(ply)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		g.GoTo(ply)
+		if board != g.Board() {
+			t.Errorf("Ply %d: BoardAt and GoTo disagree", ply)
+		}
+	}
+
+	if err := g.GoTo(5); err == nil {
+		t.Errorf("Expected an error going past the last move")
+	}
+	if _, err := g.BoardAt(-1); err == nil {
+		t.Errorf("Expected an error for a negative ply")
+	}
+}
+
+func TestGamePlayChecksMoves(t *testing.T) {
+	g := NewGame(English)
+
+	if err := g.Play(NewMove(2, English.SquarePos(22), English.SquarePos(18))); err == nil {
+		t.Errorf("Expected an error playing out of turn")
+	}
+
+	if err := g.Play(NewMove(1, English.SquarePos(12), English.SquarePos(21))); err == nil {
+		t.Errorf("Expected an error playing an illegal move")
+	}
+
+	if g.Ply() != 0 {
+		t.Errorf("Illegal moves shouldn't be played")
+	}
+}
+
+func TestGameResult(t *testing.T) {
+	board, player, err := ParseFEN(English, "W:W18:B14")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewGameFromPosition(board, player)
+	if g.Over() || g.Result() != ResultUnknown {
+		t.Errorf("Game shouldn't be over yet")
+	}
+
+	playMoves(t, g, "18x9")
+	if !g.Over() || g.Result() != ResultWhiteWins {
+		t.Errorf("Expected White to have won, found %s", g.Result())
+	}
+
+	if err := g.Play(NewMove(1, English.SquarePos(1), English.SquarePos(5))); err == nil {
+		t.Errorf("Expected no moves after the game is over")
+	}
+}
+
+func TestGamePDN(t *testing.T) {
+	g := NewGame(English)
+	playMoves(t, g, "11-15", "23-19", "8-11", "22-17")
+
+	pdn := g.PDN()
+	if !strings.Contains(pdn.String(), "1. 11-15 23-19 2. 8-11 22-17 *") {
+		t.Errorf("Unexpected PDN:\n%s", pdn)
+	}
+
+	again, err := NewGameFromPDN(pdn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Ply() != 4 || again.Board().FEN(again.Player()) != g.Board().FEN(g.Player()) {
+		t.Errorf("Game from PDN doesn't match")
+	}
+}
+
+func TestGameRepetition(t *testing.T) {
+	board, player, err := ParseFEN(English, "B:WK29:BK4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewGameFromPosition(board, player)
+	playMoves(t, g, "4-8", "29-25", "8-4", "25-29")
+	if g.Repetitions() != 2 || g.Over() {
+		t.Errorf("Expected a second occurrence, found %d", g.Repetitions())
+	}
+
+	playMoves(t, g, "4-8", "29-25", "8-4", "25-29")
+	if g.DrawReason() != DrawRepetition || g.Result() != ResultDraw {
+		t.Errorf("Expected a draw by repetition, found '%s'", g.DrawReason())
+	}
+
+	g.Undo()
+	if g.Over() {
+		t.Errorf("Expected the draw to be undone")
+	}
+}
+
+func TestGameQuietMoves(t *testing.T) {
+	// Wander the kings around at random, avoiding repetition and keeping
+	// out of each other's reach, until the 40 move rule comes into play
+	rng := rand.New(rand.NewSource(5))
+	for attempt := 0; attempt < 100; attempt++ {
+		board, player, _ := ParseFEN(English, "B:WK29:BK4")
+		g := NewGameFromPosition(board, player)
+		seen := map[uint64]bool{board.Hash(player): true}
+
+		for g.QuietPlies() < 2*English.QuietMoves {
+			if g.Over() {
+				t.Fatalf("Game over after %d quiet moves", g.QuietPlies())
+			}
+
+			fresh := []*Move{}
+			for _, move := range g.LegalMoves() {
+				next := g.Board().Apply(move)
+				replies := next.AllMoves(Opposition(g.Player()))
+				if len(replies) > 0 && !next.IsCapture(replies[0]) && !seen[next.Hash(Opposition(g.Player()))] {
+					fresh = append(fresh, move)
+				}
+			}
+			if len(fresh) == 0 {
+				break
+			}
+
+			playMove(t, g, fresh[rng.Intn(len(fresh))])
+			seen[g.Board().Hash(g.Player())] = true
+		}
+
+		if g.QuietPlies() == 2*English.QuietMoves {
+			if g.DrawReason() != DrawQuietMoves || g.Result() != ResultDraw {
+				t.Errorf("Expected a draw by the 40 move rule, found '%s'", g.DrawReason())
+			}
+			return
+		}
+	}
+
+	t.Errorf("Never reached the 40 move rule")
+}
+
+// wander plays up to `plies` moves at random, none leaving a capture to
+// make or repeating a position, and returns how many it managed
+func wander(t *testing.T, g *Game, plies int, rng *rand.Rand) int {
+	seen := map[uint64]bool{g.Board().Hash(g.Player()): true}
+	for played := 0; played < plies; played++ {
+		fresh := []*Move{}
+		for _, move := range g.LegalMoves() {
+			next := g.Board().Apply(move)
+			replies := next.AllMoves(Opposition(g.Player()))
+			if len(replies) > 0 && !next.IsCapture(replies[0]) && !seen[next.Hash(Opposition(g.Player()))] {
+				fresh = append(fresh, move)
+			}
+		}
+		if len(fresh) == 0 || g.Over() {
+			return played
+		}
+
+		playMove(t, g, fresh[rng.Intn(len(fresh))])
+		seen[g.Board().Hash(g.Player())] = true
+	}
+
+	return plies
+}
+
+func TestGameInternationalDraws(t *testing.T) {
+	positions := []struct {
+		fen    string
+		plies  int
+		reason string
+	}{
+		{"W:WK1,K50:BK25", 10, DrawEndgame},          // Two kings against one: 5 moves each
+		{"W:WK1,44:BK25", 10, DrawEndgame},           // A king and a man against one
+		{"W:WK1,K50,44:BK25", 32, DrawEndgame},       // Two kings and a man: 16 moves each
+		{"W:WK1,K50,K3:BK25", 32, DrawEndgame},       // Three against one: 16 moves each
+		{"W:WK1,K50,K3,K4:BK25", 50, DrawQuietMoves}, // Four: 25 moves of kings only
+	}
+
+	for _, pos := range positions {
+		rng := rand.New(rand.NewSource(1))
+		for attempt := 0; ; attempt++ {
+			if attempt == 100 {
+				t.Fatalf("%s: never reached %d plies", pos.fen, pos.plies)
+			}
+
+			board, player, err := ParseFEN(International, pos.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g := NewGameFromPosition(board, player)
+			if wander(t, g, pos.plies-1, rng) != pos.plies-1 || g.Over() {
+				continue
+			}
+			if wander(t, g, 1, rng) != 1 {
+				continue
+			}
+
+			if g.DrawReason() != pos.reason {
+				t.Errorf("%s: expected a draw by the %s after %d plies, found '%s'", pos.fen, pos.reason, pos.plies, g.DrawReason())
+			}
+			break
+		}
+	}
+}
+
+// playMove plays `move`, failing the test if it's illegal
+func playMove(t *testing.T, g *Game, move *Move) {
+	if err := g.Play(move); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGameAgreedDraw(t *testing.T) {
+	g := NewGame(English)
+	playMoves(t, g, "11-15")
+
+	if err := g.AgreeDraw(); err != nil {
+		t.Fatal(err)
+	}
+	if g.DrawReason() != DrawAgreed || g.PDN().Result != ResultDraw {
+		t.Errorf("Expected an agreed draw")
+	}
+	if err := g.AgreeDraw(); err == nil {
+		t.Errorf("Expected the game to be over")
+	}
+
+	g.Undo()
+	if g.Over() {
+		t.Errorf("Expected taking back a move to cancel the agreement")
+	}
+
+	// Captures and men moving reset the count of quiet moves
+	playMoves(t, g, "11-15", "22-18", "15x22")
+	if g.QuietPlies() != 0 {
+		t.Errorf("Expected no quiet moves, found %d", g.QuietPlies())
+	}
+}
+
+func TestGameAgreedDrawFromPDN(t *testing.T) {
+	g := NewGame(English)
+	playMoves(t, g, "11-15", "23-19")
+	g.AgreeDraw()
+
+	again, err := NewGameFromPDN(g.PDN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.DrawReason() != DrawAgreed {
+		t.Errorf("Expected the agreed draw to be kept, found '%s'", again.DrawReason())
+	}
+}
+
+func TestGameForfeit(t *testing.T) {
+	g := NewGame(English)
+	playMoves(t, g, "11-15")
+	if err := g.Forfeit(2); err != nil {
+		t.Fatal(err)
+	}
+
+	if g.Result() != ResultBlackWins || g.Forfeited() != 2 {
+		t.Errorf("Expected Black to win, found %s", g.Result())
+	}
+	if err := g.Forfeit(1); err == nil {
+		t.Errorf("Expected an error forfeiting a finished game")
+	}
+
+	// Kept when saved, and forgotten when the move is taken back
+	again, err := NewGameFromPDN(g.PDN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Forfeited() != 2 {
+		t.Errorf("Expected the forfeit to be kept, found %d", again.Forfeited())
+	}
+
+	g.Undo()
+	if g.Over() {
+		t.Errorf("Expected the game to carry on after undo")
+	}
+}
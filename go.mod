@@ -0,0 +1,3 @@
+module github.com/xpqz/draughts
+
+go 1.21
@@ -0,0 +1,181 @@
+package match
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/xpqz/draughts"
+)
+
+// External is a Player that's another program, speaking the text protocol
+// of package protocol: UCI, with draughts moves and positions. One copy is
+// started for each game played at once.
+type External struct {
+	Label   string
+	Command []string
+	GoArgs  string // What to search for, as the arguments to "go", e.g. "movetime 100"
+
+	start func() (*process, error) // Starts a copy, unless there's one idle
+
+	lock sync.Mutex
+	idle []*process
+	all  []*process
+}
+
+// process is a running copy of an external engine
+type process struct {
+	cmd *exec.Cmd
+	in  io.WriteCloser
+	out *bufio.Scanner
+}
+
+// NewExternal is the constructor. The program isn't started until it's
+// needed.
+func NewExternal(label string, command []string, goArgs string) *External {
+	x := &External{Label: label, Command: command, GoArgs: goArgs}
+	x.start = x.run
+	return x
+}
+
+// run starts a copy of the program
+func (x *External) run() (*process, error) {
+	if len(x.Command) == 0 {
+		return nil, fmt.Errorf("%s: no command", x.Label)
+	}
+
+	cmd := exec.Command(x.Command[0], x.Command[1:]...)
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("%s: %s", x.Label, err)
+	}
+
+	return &process{cmd: cmd, in: in, out: bufio.NewScanner(out)}, nil
+}
+
+// Name is the engine's label
+func (x *External) Name() string {
+	return x.Label
+}
+
+// send writes a command to the program
+func (p *process) send(format string, args ...interface{}) error {
+	_, err := fmt.Fprintf(p.in, format+"\n", args...)
+	return err
+}
+
+// await reads the program's output up to a line starting with `word`, and
+// returns the rest of that line
+func (p *process) await(word string) (string, error) {
+	for p.out.Scan() {
+		fields := strings.Fields(p.out.Text())
+		if len(fields) > 0 && fields[0] == word {
+			return strings.Join(fields[1:], " "), nil
+		}
+	}
+
+	if err := p.out.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("Engine stopped before '%s'", word)
+}
+
+// acquire returns an idle copy of the program, or starts a new one
+func (x *External) acquire() (*process, error) {
+	x.lock.Lock()
+	if len(x.idle) > 0 {
+		p := x.idle[len(x.idle)-1]
+		x.idle = x.idle[:len(x.idle)-1]
+		x.lock.Unlock()
+		return p, nil
+	}
+	x.lock.Unlock()
+
+	p, err := x.start()
+	if err != nil {
+		return nil, err
+	}
+
+	x.lock.Lock()
+	x.all = append(x.all, p)
+	x.lock.Unlock()
+
+	if err := p.send("uci"); err != nil {
+		return nil, err
+	}
+	if _, err := p.await("uciok"); err != nil {
+		return nil, fmt.Errorf("%s: %s", x.Label, err)
+	}
+	return p, nil
+}
+
+// Move asks the program for its move
+func (x *External) Move(game *draughts.Game) (*draughts.Move, error) {
+	p, err := x.acquire()
+	if err != nil {
+		return nil, err
+	}
+
+	start, player := game.Start()
+	position := "fen " + start.FEN(player)
+	if moves := game.Moves(); len(moves) > 0 {
+		texts := []string{}
+		for i, move := range moves {
+			board, _ := game.BoardAt(i)
+			texts = append(texts, board.MoveString(move))
+		}
+		position += " moves " + strings.Join(texts, " ")
+	}
+
+	err = p.send("position %s", position)
+	if err == nil {
+		err = p.send("go %s", x.GoArgs)
+	}
+	var text string
+	if err == nil {
+		text, err = p.await("bestmove")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", x.Label, err)
+	}
+
+	x.lock.Lock()
+	x.idle = append(x.idle, p)
+	x.lock.Unlock()
+
+	move, err := game.Board().ResolveMove(text, game.Player())
+	if err != nil {
+		return nil, fmt.Errorf("%s played '%s': %s", x.Label, text, err)
+	}
+	return move, nil
+}
+
+// Close tells every copy of the program to quit, and waits for them to
+func (x *External) Close() error {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	var firstErr error
+	for _, p := range x.all {
+		p.send("quit")
+		p.in.Close()
+		if p.cmd != nil {
+			if err := p.cmd.Wait(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	x.all, x.idle = nil, nil
+	return firstErr
+}
@@ -0,0 +1,322 @@
+// Package match plays engines against each other, for telling whether a
+// change makes one stronger. Games start from tournament ballots, each
+// played twice with the colours reversed, so that neither side gains from
+// an opening that favours one colour.
+package match
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xpqz/draughts"
+)
+
+// DefaultMaxPlies is how long a game may go on before it's called a draw
+const DefaultMaxPlies = 300
+
+// matchTableSize is the transposition table size of each engine, smaller
+// than usual as a match may run several at once
+const matchTableSize = 1 << 18
+
+// Player picks the moves for one side of a game. As a match may play
+// several games at once, a Player must be safe to use from several
+// goroutines.
+type Player interface {
+	// Name identifies the player in results and PDN
+	Name() string
+
+	// Move picks a move for the player to move in `game`
+	Move(game *draughts.Game) (*draughts.Move, error)
+}
+
+// Engine is a Player using this package's own search
+type Engine struct {
+	Label     string
+	Limits    draughts.SearchLimits
+	Evaluator draughts.Evaluator // Nil for the default weights
+
+	lock sync.Mutex
+	idle []*draughts.Engine // One for each game played at once
+}
+
+// NewEngine is the constructor
+func NewEngine(label string, limits draughts.SearchLimits) *Engine {
+	return &Engine{Label: label, Limits: limits}
+}
+
+// Name is the engine's label
+func (e *Engine) Name() string {
+	return e.Label
+}
+
+// Move searches the position for the best move
+func (e *Engine) Move(game *draughts.Game) (*draughts.Move, error) {
+	e.lock.Lock()
+	var engine *draughts.Engine
+	if len(e.idle) > 0 {
+		engine, e.idle = e.idle[len(e.idle)-1], e.idle[:len(e.idle)-1]
+	}
+	e.lock.Unlock()
+
+	if engine == nil {
+		engine = draughts.NewEngine(matchTableSize)
+		engine.Evaluator = e.Evaluator
+	}
+
+	score := engine.Search(game.Board(), game.Player(), e.Limits)
+
+	e.lock.Lock()
+	e.idle = append(e.idle, engine)
+	e.lock.Unlock()
+
+	if score.Move == nil {
+		return nil, fmt.Errorf("%s found no move", e.Label)
+	}
+	return score.Move, nil
+}
+
+// ParsePlayer sets up a player from a description: comma separated
+// settings, name=, depth=, time= (per move, in milliseconds) and weights=
+// (a file of evaluation weights, see draughts.ParseWeights), then
+// optionally cmd= and the command line of an external engine, taking up
+// the rest. Without a depth or time, the search is to depth 6.
+func ParsePlayer(spec string) (Player, error) {
+	name, command := "", []string(nil)
+	limits := draughts.SearchLimits{}
+	var evaluator draughts.Evaluator
+
+	for spec != "" {
+		setting, rest, _ := strings.Cut(spec, ",")
+		key, value, ok := strings.Cut(setting, "=")
+		if !ok {
+			return nil, fmt.Errorf("Expected key=value, found '%s'", setting)
+		}
+
+		if key == "cmd" {
+			_, value, _ = strings.Cut(spec, "=")
+			if command = strings.Fields(value); len(command) == 0 {
+				return nil, fmt.Errorf("Expected a command after cmd=")
+			}
+			break
+		}
+
+		spec = rest
+		if key == "name" {
+			name = value
+			continue
+		}
+
+		if key == "weights" {
+			data, err := os.ReadFile(value)
+			if err != nil {
+				return nil, err
+			}
+			weights, err := draughts.ParseWeights(data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", value, err)
+			}
+			evaluator = weights
+			continue
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("Bad %s '%s'", key, value)
+		}
+		switch key {
+		case "depth":
+			limits.Depth = n
+		case "time":
+			limits.Budget = time.Duration(n) * time.Millisecond
+		default:
+			return nil, fmt.Errorf("Unknown setting '%s'", key)
+		}
+	}
+
+	if limits.Depth == 0 && limits.Budget == 0 {
+		limits.Depth = 6
+	}
+
+	if command != nil {
+		if evaluator != nil {
+			return nil, fmt.Errorf("Weights are for the built-in engine, not %s", command[0])
+		}
+		if name == "" {
+			name = command[0]
+		}
+		goArgs := []string{}
+		if limits.Depth > 0 {
+			goArgs = append(goArgs, "depth", strconv.Itoa(limits.Depth))
+		}
+		if limits.Budget > 0 {
+			goArgs = append(goArgs, "movetime", strconv.Itoa(int(limits.Budget/time.Millisecond)))
+		}
+		return NewExternal(name, command, strings.Join(goArgs, " ")), nil
+	}
+
+	if name == "" {
+		parts := []string{}
+		if limits.Depth > 0 {
+			parts = append(parts, fmt.Sprintf("depth %d", limits.Depth))
+		}
+		if limits.Budget > 0 {
+			parts = append(parts, limits.Budget.String())
+		}
+		name = strings.Join(parts, " ")
+	}
+	engine := NewEngine(name, limits)
+	engine.Evaluator = evaluator
+	return engine, nil
+}
+
+// Result is one game of a match
+type Result struct {
+	Ballot draughts.Ballot
+	Red    int // Which of the match's players was Red: 0 or 1
+	Game   *draughts.Game
+}
+
+// Score is the game's result for the match's first player: 1 for a win,
+// 0.5 for a draw and 0 for a loss
+func (r Result) Score() float64 {
+	switch r.Game.Result() {
+	case draughts.WinResult(1):
+		return float64(1 - r.Red)
+	case draughts.WinResult(2):
+		return float64(r.Red)
+	}
+	return 0.5
+}
+
+// Match plays each of its ballots twice, once with each player as Red
+type Match struct {
+	Players     [2]Player
+	Ballots     []draughts.Ballot
+	MaxPlies    int   // Zero means DefaultMaxPlies
+	Concurrency int   // Games played at once; zero means one
+	SPRT        *SPRT // If set, the match stops once the test is decided
+}
+
+// pairing is a game to be played: a ballot, and who's Red
+type pairing struct {
+	ballot draughts.Ballot
+	red    int
+}
+
+// played is a game that's been played, or failed to be
+type played struct {
+	result Result
+	err    error
+}
+
+// Run plays the match, calling `report`, if it isn't nil, with each game
+// as it finishes and the results so far. Games finish in the order they're
+// started only if they're played one at a time. The match stops early if
+// the SPRT is decided, once the games in progress have finished.
+func (m *Match) Run(report func(Result, Stats)) ([]Result, Stats, error) {
+	workers := max(m.Concurrency, 1)
+	games := make(chan pairing)
+	done := make(chan played)
+	stop := make(chan struct{})
+	halt := sync.OnceFunc(func() { close(stop) })
+
+	go func() {
+		defer close(games)
+		for _, ballot := range m.Ballots {
+			for red := 0; red < 2; red++ {
+				select {
+				case games <- pairing{ballot, red}:
+				case <-stop:
+					return
+				}
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for g := range games {
+				result, err := m.play(g.ballot, g.red)
+				done <- played{result, err}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	results := []Result{}
+	stats := Stats{}
+	var firstErr error
+	for p := range done {
+		if p.err != nil {
+			if firstErr == nil {
+				firstErr = p.err
+			}
+			halt()
+			continue
+		}
+
+		results = append(results, p.result)
+		stats.Add(p.result.Score())
+		if report != nil {
+			report(p.result, stats)
+		}
+
+		if m.SPRT != nil && m.SPRT.Test(stats) != SPRTContinue {
+			halt()
+		}
+	}
+
+	return results, stats, firstErr
+}
+
+// play plays one game from `ballot`, with player `red` as Red
+func (m *Match) play(ballot draughts.Ballot, red int) (Result, error) {
+	game, err := draughts.NewGameFromBallot(draughts.English, ballot)
+	if err != nil {
+		return Result{}, err
+	}
+
+	maxPlies := m.MaxPlies
+	if maxPlies <= 0 {
+		maxPlies = DefaultMaxPlies
+	}
+
+	sides := [2]Player{m.Players[red], m.Players[1-red]}
+	for !game.Over() {
+		if game.Ply() >= maxPlies {
+			game.AgreeDraw()
+			break
+		}
+
+		side := sides[game.Player()-1]
+		move, err := side.Move(game)
+		if err != nil {
+			return Result{}, err
+		}
+		if err := game.Play(move); err != nil {
+			return Result{}, fmt.Errorf("%s: %s", side.Name(), err)
+		}
+	}
+
+	return Result{Ballot: ballot, Red: red, Game: game}, nil
+}
+
+// PDN records the game, naming the players and the ballot
+func (m *Match) PDN(r Result) *draughts.PDNGame {
+	pdn := r.Game.PDN()
+	pdn.SetTag("Event", "Match")
+	pdn.SetTag("Black", m.Players[r.Red].Name())
+	pdn.SetTag("White", m.Players[1-r.Red].Name())
+	pdn.SetTag("Opening", r.Ballot.String())
+	return pdn
+}
@@ -0,0 +1,211 @@
+package match
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xpqz/draughts"
+	"github.com/xpqz/draughts/protocol"
+)
+
+// firstMove always plays the first legal move
+type firstMove struct{}
+
+func (firstMove) Name() string {
+	return "First"
+}
+
+func (firstMove) Move(game *draughts.Game) (*draughts.Move, error) {
+	return game.LegalMoves()[0], nil
+}
+
+// threeMoveBallots reads the deck of three-move ballots
+func threeMoveBallots(t *testing.T) []draughts.Ballot {
+	ballots, err := draughts.ThreeMoveBallots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ballots
+}
+
+func TestMatchBallots(t *testing.T) {
+	ballots := []draughts.Ballot{
+		threeMoveBallots(t)[0],
+		{Removed: []int{1, 32}, Moves: []string{"11-15"}},
+	}
+	m := &Match{
+		Players: [2]Player{NewEngine("Engine", draughts.SearchLimits{Depth: 4}), firstMove{}},
+		Ballots: ballots,
+	}
+
+	reported := 0
+	results, stats, err := m.Run(func(_ Result, stats Stats) {
+		if reported++; stats.Games() != reported {
+			t.Errorf("Expected %d games counted, found %d", reported, stats.Games())
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 || reported != 4 || stats.Games() != 4 {
+		t.Fatalf("Expected each ballot played twice, found %d games", len(results))
+	}
+
+	for i, r := range results {
+		if r.Ballot.String() != ballots[i/2].String() || r.Red != i%2 {
+			t.Errorf("Game %d: expected %s with player %d as Red, found %s with %d",
+				i+1, ballots[i/2], i%2, r.Ballot, r.Red)
+		}
+		if !r.Game.Over() {
+			t.Errorf("Game %d isn't over", i+1)
+		}
+
+		// Colours reversed, the same ballot is played again
+		opening := r.Game.Moves()[:len(ballots[i/2].Moves)]
+		for j, move := range opening {
+			board, _ := r.Game.BoardAt(j)
+			if board.MoveString(move) != ballots[i/2].Moves[j] {
+				t.Errorf("Game %d: expected %s, found %s", i+1, ballots[i/2].Moves[j], board.MoveString(move))
+			}
+		}
+
+		pdn := m.PDN(r)
+		if pdn.Tag("Black") != m.Players[r.Red].Name() || pdn.Tag("Opening") != r.Ballot.String() {
+			t.Errorf("Game %d: Black is %s, opening %s", i+1, pdn.Tag("Black"), pdn.Tag("Opening"))
+		}
+	}
+
+	// The engine ought to beat a player that doesn't think
+	if stats.Score() < 0.75 {
+		t.Errorf("Expected the engine to score at least 3 of 4, found %s", stats)
+	}
+}
+
+func TestMatchMaxPlies(t *testing.T) {
+	m := &Match{
+		Players:  [2]Player{firstMove{}, firstMove{}},
+		Ballots:  threeMoveBallots(t)[:1],
+		MaxPlies: 10,
+	}
+
+	results, _, err := m.Run(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.Game.Ply() != 10 || r.Game.Result() != draughts.ResultDraw || r.Score() != 0.5 {
+			t.Errorf("Expected a draw after 10 plies, found %s after %d", r.Game.Result(), r.Game.Ply())
+		}
+	}
+}
+
+func TestMatchConcurrentSPRT(t *testing.T) {
+	m := &Match{
+		Players:     [2]Player{NewEngine("Engine", draughts.SearchLimits{Depth: 2}), firstMove{}},
+		Ballots:     threeMoveBallots(t)[:40],
+		Concurrency: 4,
+		SPRT:        &SPRT{Elo0: 0, Elo1: 50, Alpha: 0.05, Beta: 0.05},
+	}
+
+	verdict := SPRTContinue
+	results, stats, err := m.Run(func(_ Result, stats Stats) {
+		if verdict == SPRTContinue {
+			verdict = m.SPRT.Test(stats)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The engine is so much stronger that the test is soon decided, and
+	// only the games already started are finished after that
+	if len(results) >= 40 || verdict != SPRTAcceptH1 {
+		t.Errorf("Expected the SPRT to stop the match early, found %s after %d games", stats, len(results))
+	}
+}
+
+func TestParsePlayer(t *testing.T) {
+	tests := []struct {
+		spec, name string
+		limits     draughts.SearchLimits
+	}{
+		{"", "depth 6", draughts.SearchLimits{Depth: 6}},
+		{"depth=8", "depth 8", draughts.SearchLimits{Depth: 8}},
+		{"name=quick,time=50", "quick", draughts.SearchLimits{Budget: 50 * time.Millisecond}},
+	}
+	for _, test := range tests {
+		player, err := ParsePlayer(test.spec)
+		if err != nil {
+			t.Errorf("%s: %s", test.spec, err)
+			continue
+		}
+		engine, ok := player.(*Engine)
+		if !ok || engine.Name() != test.name || engine.Limits.Depth != test.limits.Depth ||
+			engine.Limits.Budget != test.limits.Budget {
+			t.Errorf("%s: expected %s, found %+v", test.spec, test.name, player)
+		}
+	}
+
+	player, err := ParsePlayer("time=100,cmd=./other -engine -x=1,2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	external, ok := player.(*External)
+	if !ok || external.Name() != "./other" || strings.Join(external.Command, " ") != "./other -engine -x=1,2" ||
+		external.GoArgs != "movetime 100" {
+		t.Errorf("Expected an external engine, found %+v", player)
+	}
+
+	path := filepath.Join(t.TempDir(), "weights.json")
+	os.WriteFile(path, []byte(`{"piece": 100}`), 0644)
+	player, err = ParsePlayer("weights=" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if weights, ok := player.(*Engine).Evaluator.(draughts.Weights); !ok || weights.Piece != 100 {
+		t.Errorf("Expected a piece weight of 100, found %+v", player.(*Engine).Evaluator)
+	}
+
+	for _, spec := range []string{"depth", "depth=x", "time=-5", "colour=red", "cmd=",
+		"weights=missing.json", "weights=" + path + ",cmd=./other"} {
+		if _, err := ParsePlayer(spec); err == nil {
+			t.Errorf("Expected an error parsing '%s'", spec)
+		}
+	}
+}
+
+func TestExternal(t *testing.T) {
+	x := NewExternal("Text", nil, "depth 2")
+	started := 0
+	x.start = func() (*process, error) {
+		started++
+		inReader, inWriter := io.Pipe()
+		outReader, outWriter := io.Pipe()
+		go func() {
+			protocol.NewTextEngine(outWriter).Run(inReader)
+			outWriter.Close()
+		}()
+		return &process{in: inWriter, out: bufio.NewScanner(outReader)}, nil
+	}
+
+	m := &Match{
+		Players:  [2]Player{x, firstMove{}},
+		Ballots:  []draughts.Ballot{{Removed: []int{4, 29}, Moves: []string{"11-15"}}},
+		MaxPlies: 40,
+	}
+	results, _, err := m.Run(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || started != 1 {
+		t.Errorf("Expected 2 games from one engine, found %d from %d", len(results), started)
+	}
+	if err := x.Close(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,121 @@
+package match
+
+import (
+	"fmt"
+	"math"
+)
+
+// Stats counts a match's results from the first player's point of view
+type Stats struct {
+	Wins, Draws, Losses int
+}
+
+// Add counts a game with the first player's `score`: 1, 0.5 or 0
+func (s *Stats) Add(score float64) {
+	switch score {
+	case 1:
+		s.Wins++
+	case 0:
+		s.Losses++
+	default:
+		s.Draws++
+	}
+}
+
+// Games is the number of games counted
+func (s Stats) Games() int {
+	return s.Wins + s.Draws + s.Losses
+}
+
+// Score is the first player's average score a game
+func (s Stats) Score() float64 {
+	if s.Games() == 0 {
+		return 0.5
+	}
+	return (float64(s.Wins) + float64(s.Draws)/2) / float64(s.Games())
+}
+
+// variance is that of the score of a single game
+func (s Stats) variance() float64 {
+	m, n := s.Score(), float64(s.Games())
+	return (float64(s.Wins)*(1-m)*(1-m) + float64(s.Draws)*(0.5-m)*(0.5-m) +
+		float64(s.Losses)*m*m) / n
+}
+
+// eloDifference is the difference in rating that gives an expected score
+// of `score`
+func eloDifference(score float64) float64 {
+	return -400 * math.Log10(1/score-1)
+}
+
+// expectedScore is the inverse of eloDifference
+func expectedScore(elo float64) float64 {
+	return 1 / (1 + math.Pow(10, -elo/400))
+}
+
+// Elo estimates how much stronger the first player is, in Elo, and the
+// margin of error either side of that, at 95% confidence. If the first
+// player has scored every point, or none, the difference is infinite.
+func (s Stats) Elo() (float64, float64) {
+	m := s.Score()
+	if s.Games() == 0 || m == 0 || m == 1 {
+		return eloDifference(m), math.Inf(1)
+	}
+
+	deviation := math.Sqrt(s.variance() / float64(s.Games()))
+	low := eloDifference(math.Max(m-1.96*deviation, 0))
+	high := eloDifference(math.Min(m+1.96*deviation, 1))
+	return eloDifference(m), (high - low) / 2
+}
+
+// String gives the counts and the Elo difference
+func (s Stats) String() string {
+	elo, margin := s.Elo()
+	return fmt.Sprintf("+%d =%d -%d  Elo %+.1f ± %.1f", s.Wins, s.Draws, s.Losses, elo, margin)
+}
+
+// SPRT is a sequential probability ratio test: it stops a match as soon as
+// the results say, with the confidence asked for, that the first player is
+// no better than Elo0 stronger, or at least Elo1 stronger
+type SPRT struct {
+	Elo0, Elo1  float64
+	Alpha, Beta float64 // Chances of accepting Elo1 when it's false, and Elo0
+}
+
+// The SPRT's verdicts
+const (
+	SPRTContinue = iota
+	SPRTAcceptH0 // No better than Elo0
+	SPRTAcceptH1 // At least Elo1
+)
+
+// Bounds are the log-likelihood ratios below which Elo0 is accepted and
+// above which Elo1 is
+func (t SPRT) Bounds() (float64, float64) {
+	return math.Log(t.Beta / (1 - t.Alpha)), math.Log((1 - t.Beta) / t.Alpha)
+}
+
+// LLR is the log-likelihood ratio of Elo1 against Elo0, given the results
+// so far, by the normal approximation to the distribution of scores
+func (t SPRT) LLR(s Stats) float64 {
+	variance := s.variance()
+	if s.Games() == 0 || variance == 0 {
+		return 0
+	}
+
+	s0, s1 := expectedScore(t.Elo0), expectedScore(t.Elo1)
+	return float64(s.Games()) * (s1 - s0) * (2*s.Score() - s0 - s1) / (2 * variance)
+}
+
+// Test gives the verdict on the results so far
+func (t SPRT) Test(s Stats) int {
+	llr := t.LLR(s)
+	lower, upper := t.Bounds()
+	switch {
+	case llr <= lower:
+		return SPRTAcceptH0
+	case llr >= upper:
+		return SPRTAcceptH1
+	}
+	return SPRTContinue
+}
@@ -0,0 +1,66 @@
+package match
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStatsElo(t *testing.T) {
+	s := Stats{}
+	for i, score := range []float64{1, 1, 0.5, 0} {
+		s.Add(score)
+		if s.Games() != i+1 {
+			t.Errorf("Expected %d games, found %d", i+1, s.Games())
+		}
+	}
+	if s.Wins != 2 || s.Draws != 1 || s.Losses != 1 || s.Score() != 0.625 {
+		t.Errorf("Expected +2 =1 -1, found %s", s)
+	}
+
+	// 70% is about 147 Elo
+	s = Stats{Wins: 60, Draws: 20, Losses: 20}
+	elo, margin := s.Elo()
+	if math.Abs(elo-147.2) > 0.1 || margin < 50 || margin > 90 {
+		t.Errorf("Expected 147.2 Elo with a margin of about 70, found %.1f ± %.1f", elo, margin)
+	}
+
+	// More games, a smaller margin
+	s = Stats{Wins: 600, Draws: 200, Losses: 200}
+	if _, wider := s.Elo(); wider >= margin {
+		t.Errorf("Expected a smaller margin than %.1f, found %.1f", margin, wider)
+	}
+
+	if elo, margin := (Stats{Wins: 3}).Elo(); !math.IsInf(elo, 1) || !math.IsInf(margin, 1) {
+		t.Errorf("Expected an infinite difference with every point, found %.1f ± %.1f", elo, margin)
+	}
+	if elo, margin := (Stats{Losses: 3}).Elo(); !math.IsInf(elo, -1) || !math.IsInf(margin, 1) {
+		t.Errorf("Expected an infinite difference with no points, found %.1f ± %.1f", elo, margin)
+	}
+	if elo, _ := (Stats{}).Elo(); elo != 0 {
+		t.Errorf("Expected no difference without games, found %.1f", elo)
+	}
+}
+
+func TestSPRT(t *testing.T) {
+	test := SPRT{Elo0: 0, Elo1: 10, Alpha: 0.05, Beta: 0.05}
+	lower, upper := test.Bounds()
+	if math.Abs(lower+2.944) > 0.001 || math.Abs(upper-2.944) > 0.001 {
+		t.Errorf("Expected bounds of ±2.944, found %.3f and %.3f", lower, upper)
+	}
+
+	tests := []struct {
+		stats   Stats
+		verdict int
+	}{
+		{Stats{}, SPRTContinue},
+		{Stats{Wins: 10, Draws: 10, Losses: 10}, SPRTContinue},
+		{Stats{Wins: 2000, Draws: 1000, Losses: 1000}, SPRTAcceptH1},
+		{Stats{Wins: 1000, Draws: 1000, Losses: 2000}, SPRTAcceptH0},
+		{Stats{Draws: 100}, SPRTContinue},
+	}
+	for _, tt := range tests {
+		if verdict := test.Test(tt.stats); verdict != tt.verdict {
+			t.Errorf("%s: expected %d, found %d (LLR %.2f)", tt.stats, tt.verdict, verdict, test.LLR(tt.stats))
+		}
+	}
+}
@@ -1,10 +1,7 @@
-package main
+package draughts
 
 import (
-	"bufio"
 	"fmt"
-	"os"
-	"strconv"
 	"strings"
 )
 
@@ -24,32 +21,12 @@ func NewMove(player int, pos ...Pos) *Move {
 	return m
 }
 
-// ReadMove creates a move from stdin input
-func ReadMove(player int) (*Move, error) {
-	reader := bufio.NewReader(os.Stdin)
-	moveStr, err := reader.ReadString('\n')
-	if err != nil {
-		return nil, err
-	}
-	moveStr = strings.Trim(moveStr, " \n")
-	return ParseMove(moveStr, player)
-}
-
 // ValidJumpSequence tests if all transitions in a move skips a square
-// diagonally and in the same Y-direction
+// diagonally. Kings may change direction between jumps, so it's up to
+// Board.Validate to check direction against the piece moving.
 func (m Move) ValidJumpSequence() bool {
-	direction := 0
 	for i := 0; i < m.Length()-1; i++ {
-		first := m.Squares[i]
-		second := m.Squares[i+1]
-		jumped, dir := IsJump(first, second)
-		if jumped == false {
-			return false
-		}
-
-		if direction == 0 {
-			direction = dir
-		} else if direction != dir {
+		if jumped, _ := IsJump(m.Squares[i], m.Squares[i+1]); !jumped {
 			return false
 		}
 	}
@@ -116,19 +93,10 @@ func (m *Move) addSquare(pos Pos) {
 }
 
 // ParseMove reads a list of squares separated by space. A square is
-// represented by its numbering in the standard notation.
+// represented by its numbering in the standard notation. See also
+// Variant.ParseMove for boards other than English.
 func ParseMove(moveStr string, player int) (*Move, error) {
-	squares := strings.Split(moveStr, " ")
-	move := &Move{Player: player}
-	for _, squareStr := range squares {
-		squareNumber, err := strconv.Atoi(squareStr)
-		if err != nil || squareNumber < 1 || squareNumber > 32 {
-			return nil, fmt.Errorf("Bad square '%s'", squareStr)
-		}
-		move.addSquare(NewPosFromSquareID(squareNumber))
-	}
-
-	return move, nil
+	return English.ParseMove(moveStr, player)
 }
 
 // IsJump returns true if the move is a capture sequence
@@ -183,3 +151,31 @@ func ContainsMove(movesList []*Move, move *Move) bool {
 
 	return false
 }
+
+// CheckUserMove validates a move entered by a human player
+func CheckUserMove(board *Board, allMoves []*Move, move *Move) error {
+	// Check if the move is legal under the rules of the game
+	err := board.Validate(move)
+	if err != nil {
+		return err
+	}
+
+	// If a capture move is available, it must be taken. If several
+	// capture moves are available, it's sufficient to pick one; it does
+	// not have to be the longest, unless the variant says so.
+	if len(allMoves) > 0 && board.IsCapture(allMoves[0]) && !board.IsCapture(move) {
+		return fmt.Errorf("capture moves available and not taken")
+	}
+
+	// A capture move must be taken in its entirety. In this case it's
+	// sufficient to check that the move is present in `allMoves` as this
+	// generates the longest possible jumps.
+	if !ContainsMove(allMoves, move) {
+		if board.Variant().MaximumCapture {
+			return fmt.Errorf("the capture taking the most pieces must be played")
+		}
+		return fmt.Errorf("capture moves must be taken entirely")
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package draughts
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Alpha-beta prunes the most when the best move is tried first: one good
+// enough move and the rest needn't be looked at. So before searching a
+// node's moves, we sort them by how likely each is to be best. First the
+// move the transposition table or the previous iteration found best, then
+// captures, taking the most pieces first, then the killer moves, quiet
+// moves that refuted something else at the same ply, and last the rest, by
+// how often each has caused cut-offs anywhere in the tree, its history.
+
+// Order keys; a higher key is searched sooner
+const (
+	hashMoveOrder = 1 << 30
+	captureOrder  = 1 << 24 // Plus the number of pieces taken
+	killerOrder   = 1 << 22 // Less the killer's slot
+	historyLimit  = killerOrder - 2
+)
+
+// numKillers is the number of killer moves remembered for each ply
+const numKillers = 2
+
+// moveOrder is what a search learns about which moves to try first
+type moveOrder struct {
+	killers [maxSearchDepth][numKillers]*Move
+
+	// history is indexed by player, then the squares a move starts and
+	// ends on
+	history [2][MaxSize * MaxSize][MaxSize * MaxSize]int
+}
+
+// squareIndex numbers a position for the history table
+func squareIndex(p Pos) int {
+	return p.Y*MaxSize + p.X
+}
+
+// moveKeys sorts `moves` by their keys, highest first, keeping the order of
+// moves with the same key
+type moveKeys struct {
+	moves []*Move
+	keys  []int
+}
+
+func (mk moveKeys) Len() int           { return len(mk.moves) }
+func (mk moveKeys) Less(i, j int) bool { return mk.keys[i] > mk.keys[j] }
+func (mk moveKeys) Swap(i, j int) {
+	mk.moves[i], mk.moves[j] = mk.moves[j], mk.moves[i]
+	mk.keys[i], mk.keys[j] = mk.keys[j], mk.keys[i]
+}
+
+// sort puts `moves`, at `ply`, in the order to search them in, with
+// `first`, if it's one of them, first
+func (o *moveOrder) sort(b *Board, moves []*Move, first *Move, ply int) {
+	keys := make([]int, len(moves))
+	for i, move := range moves {
+		keys[i] = o.key(b, move, first, ply)
+	}
+
+	sort.Stable(moveKeys{moves, keys})
+}
+
+// key is how early to search `move`
+func (o *moveOrder) key(b *Board, move *Move, first *Move, ply int) int {
+	if first != nil && move.Equals(first) {
+		return hashMoveOrder
+	}
+
+	if taken := len(b.CapturedSquares(move)); taken > 0 {
+		return captureOrder + taken
+	}
+
+	if ply < maxSearchDepth {
+		for i, killer := range o.killers[ply] {
+			if killer != nil && move.Equals(killer) {
+				return killerOrder - i
+			}
+		}
+	}
+
+	return o.history[move.Player-1][squareIndex(move.Squares[0])][squareIndex(move.Squares[move.Length()-1])]
+}
+
+// cutoff learns from `move` causing a cut-off at `ply`, `depth` plies from
+// the horizon. Captures are tried early anyway, so only quiet moves count.
+func (o *moveOrder) cutoff(b *Board, move *Move, depth, ply int) {
+	if b.IsCapture(move) {
+		return
+	}
+
+	if ply < maxSearchDepth && (o.killers[ply][0] == nil || !move.Equals(o.killers[ply][0])) {
+		copy(o.killers[ply][1:], o.killers[ply][:numKillers-1])
+		o.killers[ply][0] = move
+	}
+
+	// Cut-offs far from the horizon prune more, so count for more
+	h := &o.history[move.Player-1][squareIndex(move.Squares[0])][squareIndex(move.Squares[move.Length()-1])]
+	*h = min(*h+depth*depth, historyLimit)
+}
+
+// SearchStats counts what a search did, to show how well it prunes
+type SearchStats struct {
+	Nodes        int // Positions visited, including by the quiescence search
+	Cutoffs      int // Nodes whose search was cut short by a refutation
+	FirstCutoffs int // Of those, the ones refuted by the first move tried
+
+	// BranchingFactor is how many times the nodes of the last iteration
+	// outnumber those of the one before: how much a ply deeper costs
+	BranchingFactor float64
+}
+
+// FirstCutoffRate is the share of cut-offs made by the first move tried;
+// the closer to 1, the better the moves are ordered
+func (st SearchStats) FirstCutoffRate() float64 {
+	if st.Cutoffs == 0 {
+		return 0
+	}
+	return float64(st.FirstCutoffs) / float64(st.Cutoffs)
+}
+
+// String gives the statistics in a line
+func (st SearchStats) String() string {
+	return fmt.Sprintf("%d nodes, branching factor %.2f, %d cut-offs, %.1f%% by the first move",
+		st.Nodes, st.BranchingFactor, st.Cutoffs, 100*st.FirstCutoffRate())
+}
@@ -0,0 +1,74 @@
+package draughts
+
+import "testing"
+
+func TestMoveOrder(t *testing.T) {
+	o := &moveOrder{}
+	board := NewBoard()
+	moves := board.AllMoves(1)
+	text := func(moves []*Move) []string {
+		texts := []string{}
+		for _, move := range moves {
+			texts = append(texts, board.MoveString(move))
+		}
+		return texts
+	}
+
+	// Nothing learned yet: the first move given, then board order
+	first := moves[3]
+	o.sort(board, moves, first, 0)
+	if !moves[0].Equals(first) {
+		t.Errorf("Expected %s first, found %v", board.MoveString(first), text(moves))
+	}
+
+	// Killers come next, the latest first, then moves by history
+	killer, older, historic := moves[6], moves[5], moves[4]
+	o.cutoff(board, older, 3, 0)
+	o.cutoff(board, killer, 3, 0)
+	o.cutoff(board, historic, 5, 2)
+	o.sort(board, moves, first, 0)
+	if !moves[1].Equals(killer) || !moves[2].Equals(older) || !moves[3].Equals(historic) {
+		t.Errorf("Expected %s, %s and %s after the first move, found %v", board.MoveString(killer),
+			board.MoveString(older), board.MoveString(historic), text(moves))
+	}
+
+	// Killers are per ply
+	if o.key(board, killer, nil, 1) >= killerOrder-numKillers {
+		t.Errorf("Expected %s to be a killer only at ply 0", board.MoveString(killer))
+	}
+
+	// Captures taking the most pieces come first
+	board, player, err := ParseFEN(English, "B:W18,27,19:B14,15")
+	if err != nil {
+		t.Fatal(err)
+	}
+	moves = board.AllMoves(player)
+	o.sort(board, moves, nil, 0)
+	if texts := text(moves); len(texts) != 3 || texts[2] != "15x22" {
+		t.Errorf("Expected the single jump 15x22 last, found %v", texts)
+	}
+}
+
+func TestSearchStats(t *testing.T) {
+	score := NewEngine(DefaultTableSize).Search(NewBoard(), 1, SearchLimits{Depth: 8})
+	st := score.Stats
+	if st.Nodes == 0 || st.Cutoffs == 0 || st.FirstCutoffs > st.Cutoffs || st.BranchingFactor <= 1 {
+		t.Errorf("Unlikely statistics: %s", st)
+	}
+
+	// With the moves well ordered, most cut-offs come from the first
+	if rate := st.FirstCutoffRate(); rate < 0.75 {
+		t.Errorf("Expected most cut-offs by the first move, found %.1f%%", 100*rate)
+	}
+
+	// Each iteration's statistics include those before
+	reports := []SearchStats{}
+	NewEngine(DefaultTableSize).Search(NewBoard(), 1, SearchLimits{Depth: 4, Report: func(s *Score) {
+		reports = append(reports, s.Stats)
+	}})
+	for i := 1; i < len(reports); i++ {
+		if reports[i].Nodes <= reports[i-1].Nodes {
+			t.Errorf("Expected more nodes at depth %d than %d", i+1, i)
+		}
+	}
+}
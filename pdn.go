@@ -0,0 +1,697 @@
+package draughts
+
+// Portable Draughts Notation (PDN) is the standard file format for draughts
+// games, see https://en.wikipedia.org/wiki/Portable_Draughts_Notation. A
+// game is a list of [Tag "value"] pairs, followed by the moves, with move
+// numbers, {comments} and (variations), and ending in the result:
+//
+//	[Event "Casual game"]
+//	[Black "Red"]
+//	[White "Green"]
+//	[Result "0-1"]
+//
+//	1. 11-15 23-19 2. 8-11 22-17 {the Old Fourteenth} 3. 4-8 (3. 9-13) 17-13
+//	...
+//
+// Following PDN, "White" is the side at the bottom of the board, our player
+// 2, and "Black" the side at the top, player 1. The result is given as
+// White's score first.
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// PDN results
+const (
+	ResultWhiteWins = "1-0"
+	ResultBlackWins = "0-1"
+	ResultDraw      = "1/2-1/2"
+	ResultUnknown   = "*"
+)
+
+// WinResult is the PDN result when `winner` wins
+func WinResult(winner int) string {
+	if winner == 2 {
+		return ResultWhiteWins
+	}
+	return ResultBlackWins
+}
+
+// pdnResults are the result tokens we understand, mapped onto the three
+// outcomes. International games often use 2-0 and 1-1.
+var pdnResults = map[string]string{
+	"1-0":     ResultWhiteWins,
+	"2-0":     ResultWhiteWins,
+	"0-1":     ResultBlackWins,
+	"0-2":     ResultBlackWins,
+	"1/2-1/2": ResultDraw,
+	"1-1":     ResultDraw,
+	"*":       ResultUnknown,
+}
+
+// pdnWidth is the line length movetext is wrapped at
+const pdnWidth = 79
+
+// PDNTag is a single [Name "Value"] pair
+type PDNTag struct {
+	Name, Value string
+}
+
+// PDNMove is a move as written in a PDN file, such as 11-15 or 22x15x6,
+// along with any comments and alternative lines of play. A variation is an
+// alternative to this move, not a continuation of it.
+type PDNMove struct {
+	Text       string // Squares separated by '-' or 'x'
+	PreComment string // Comment before the move, at the start of a line
+	Comment    string // Comment after the move
+	Variations [][]*PDNMove
+}
+
+// PDNGame is one game from a PDN file
+type PDNGame struct {
+	Tags   []PDNTag
+	Moves  []*PDNMove // The main line
+	Result string
+}
+
+// NewPDNGame records a game played from `start`, with `moves` applied in
+// order starting with `player`. Unless that's the initial position of the
+// variant, the position is given in a FEN tag.
+func NewPDNGame(start *Board, player int, moves []*Move) *PDNGame {
+	g := &PDNGame{Result: ResultUnknown}
+	v := start.Variant()
+	if v != English {
+		g.SetTag("GameType", strconv.Itoa(v.GameType))
+	}
+
+	if start.Hash(player) != NewVariantBoard(v).Hash(v.FirstPlayer) {
+		g.SetTag("FEN", start.FEN(player))
+	}
+
+	board := start
+	for _, move := range moves {
+		g.Moves = append(g.Moves, &PDNMove{Text: board.MoveString(move)})
+		board = board.Apply(move)
+	}
+
+	return g
+}
+
+// Tag returns the value of tag `name`, or "" if not present
+func (g *PDNGame) Tag(name string) string {
+	for _, tag := range g.Tags {
+		if tag.Name == name {
+			return tag.Value
+		}
+	}
+
+	return ""
+}
+
+// SetTag sets the value of tag `name`, adding it if it's not there already.
+// Setting the Result tag also sets the result at the end of the moves.
+func (g *PDNGame) SetTag(name, value string) {
+	if name == "Result" {
+		g.Result = value
+	}
+
+	for i := range g.Tags {
+		if g.Tags[i].Name == name {
+			g.Tags[i].Value = value
+			return
+		}
+	}
+
+	g.Tags = append(g.Tags, PDNTag{name, value})
+}
+
+// Variant returns the rules the game was played by, according to its
+// GameType tag. Without one, it's English draughts.
+func (g *PDNGame) Variant() (*Variant, error) {
+	gameType := g.Tag("GameType")
+	if gameType == "" {
+		return English, nil
+	}
+
+	// The tag may carry more detail after the number, which we ignore
+	number, err := strconv.Atoi(strings.SplitN(gameType, ",", 2)[0])
+	if err != nil {
+		return nil, fmt.Errorf("Bad GameType '%s'", gameType)
+	}
+
+	return VariantByGameType(number)
+}
+
+// Start returns the position the game starts from, and the player to move:
+// the one in the FEN tag if there is one, otherwise the variant's initial
+// position
+func (g *PDNGame) Start() (*Board, int, error) {
+	v, err := g.Variant()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if fen := g.Tag("FEN"); fen != "" {
+		return ParseFEN(v, fen)
+	}
+
+	return NewVariantBoard(v), v.FirstPlayer, nil
+}
+
+// Replay plays out the main line of the game, checking that every move is
+// legal, and returns the board at the start and the moves as played
+func (g *PDNGame) Replay() (*Board, []*Move, error) {
+	start, player, err := g.Start()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Count plies from the first player's move, to get the move numbers
+	ply := 0
+	if player != start.Variant().FirstPlayer {
+		ply = 1
+	}
+
+	board := start
+	moves := []*Move{}
+	for _, pdnMove := range g.Moves {
+		move, err := board.ResolveMove(pdnMove.Text, player)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Move %d (%s): %s", ply/2+1, pdnMove.Text, err)
+		}
+		ply++
+
+		moves = append(moves, move)
+		board = board.Apply(move)
+		player = Opposition(player)
+	}
+
+	return start, moves, nil
+}
+
+// pdnSquares splits move text into its squares, which may be numbers or,
+// as in Russian draughts, algebraic like c3
+func (v *Variant) pdnSquares(text string) ([]Pos, error) {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == '-' || r == 'x' || r == ':'
+	})
+
+	squares := []Pos{}
+	for _, field := range fields {
+		if number, err := strconv.Atoi(field); err == nil {
+			if number < 1 || number > v.SquareCount() {
+				return nil, fmt.Errorf("Bad square '%s'", field)
+			}
+			squares = append(squares, v.SquarePos(number))
+			continue
+		}
+
+		// Algebraic: column letter from the left, row number from the bottom
+		row, err := strconv.Atoi(field[1:])
+		p := Pos{int(field[0] - 'a'), v.Size - row}
+		if err != nil || !v.onBoard(p) || !playable(p) {
+			return nil, fmt.Errorf("Bad square '%s'", field)
+		}
+		squares = append(squares, p)
+	}
+
+	if len(squares) < 2 {
+		return nil, fmt.Errorf("Move must visit at least two squares")
+	}
+
+	return squares, nil
+}
+
+// ResolveMove finds the legal move for `player` written as `text` in PDN.
+// A multi-jump capture may be written with just its start and end squares
+// if that's unambiguous, or with some or all of the squares in between.
+func (b Board) ResolveMove(text string, player int) (*Move, error) {
+	squares, err := b.Variant().pdnSquares(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var found *Move
+	for _, candidate := range b.AllMoves(player) {
+		if !visitsInOrder(candidate, squares) {
+			continue
+		}
+
+		if found != nil && !found.Equals(candidate) {
+			return nil, fmt.Errorf("Ambiguous move")
+		}
+		found = candidate
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("Illegal move")
+	}
+
+	return found, nil
+}
+
+// visitsInOrder is true if `move` starts and ends on the first and last of
+// `squares`, and visits the rest in order along the way
+func visitsInOrder(move *Move, squares []Pos) bool {
+	if move.Squares[0] != squares[0] || move.Squares[move.Length()-1] != squares[len(squares)-1] {
+		return false
+	}
+
+	next := 1
+	for _, square := range move.Squares[1 : move.Length()-1] {
+		if next < len(squares)-1 && square == squares[next] {
+			next++
+		}
+	}
+
+	return next == len(squares)-1
+}
+
+// String formats the game in PDN
+func (g *PDNGame) String() string {
+	// The standard tags come first, in the usual order
+	tags := append([]PDNTag{}, g.Tags...)
+	sort.SliceStable(tags, func(i, j int) bool {
+		return pdnTagRank(tags[i].Name) < pdnTagRank(tags[j].Name)
+	})
+
+	var sb strings.Builder
+	for _, tag := range tags {
+		fmt.Fprintf(&sb, "[%s \"%s\"]\n", tag.Name, pdnEscape(tag.Value))
+	}
+	sb.WriteString("\n")
+
+	// Move numbers count pairs of moves starting with the variant's first
+	// player, so a game set up with the other side to move starts at 1...
+	first := true
+	if v, err := g.Variant(); err == nil {
+		if _, player, err := g.Start(); err == nil {
+			first = player == v.FirstPlayer
+		}
+	}
+
+	tokens := pdnLine(g.Moves, 1, first)
+	result := g.Result
+	if result == "" {
+		result = ResultUnknown
+	}
+	tokens = append(tokens, result)
+
+	// Wrap the movetext
+	column := 0
+	for _, token := range tokens {
+		if column > 0 && column+1+len(token) > pdnWidth {
+			sb.WriteString("\n")
+			column = 0
+		} else if column > 0 {
+			sb.WriteString(" ")
+			column++
+		}
+		sb.WriteString(token)
+		column += len(token)
+	}
+	sb.WriteString("\n")
+
+	return sb.String()
+}
+
+// pdnLine turns a line of play into tokens for output, numbering the moves
+// from `number`. If `first` is true, the line starts with the first move of
+// a move pair.
+func pdnLine(moves []*PDNMove, number int, first bool) []string {
+	tokens := []string{}
+	needNumber := true // At the start, and after comments and variations
+	for _, move := range moves {
+		if move.PreComment != "" {
+			tokens = append(tokens, "{"+move.PreComment+"}")
+		}
+
+		if first {
+			tokens = append(tokens, fmt.Sprintf("%d.", number))
+		} else if needNumber || move.PreComment != "" {
+			tokens = append(tokens, fmt.Sprintf("%d...", number))
+		}
+		tokens = append(tokens, move.Text)
+		needNumber = false
+
+		if move.Comment != "" {
+			tokens = append(tokens, "{"+move.Comment+"}")
+			needNumber = true
+		}
+
+		for _, variation := range move.Variations {
+			line := pdnLine(variation, number, first)
+			if len(line) == 0 {
+				continue
+			}
+			line[0] = "(" + line[0]
+			line[len(line)-1] += ")"
+			tokens = append(tokens, line...)
+			needNumber = true
+		}
+
+		if !first {
+			number++
+		}
+		first = !first
+	}
+
+	return tokens
+}
+
+// pdnTagOrder is the order of the standard tags in a PDN file
+var pdnTagOrder = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+// pdnTagRank orders tags for output: the standard ones first, then the
+// rest
+func pdnTagRank(name string) int {
+	for i, standard := range pdnTagOrder {
+		if name == standard {
+			return i
+		}
+	}
+
+	return len(pdnTagOrder)
+}
+
+// pdnEscape escapes quotes and backslashes in a tag value
+func pdnEscape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+}
+
+// WritePDN writes `games` to `w`, separated by blank lines
+func WritePDN(w io.Writer, games []*PDNGame) error {
+	for i, g := range games {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+
+		if _, err := io.WriteString(w, g.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type pdnTokenKind int
+
+const (
+	pdnTag pdnTokenKind = iota
+	pdnComment
+	pdnOpen
+	pdnClose
+	pdnNumber
+	pdnMoveText
+	pdnResult
+	pdnNAG
+	pdnEOF
+)
+
+type pdnToken struct {
+	kind  pdnTokenKind
+	text  string
+	value string // Tag value
+	line  int
+}
+
+var (
+	pdnMovePattern   = regexp.MustCompile(`^[a-j]?[0-9]+([-x:][a-j]?[0-9]+)+$`)
+	pdnNumberPattern = regexp.MustCompile(`^[0-9]+\.+`)
+)
+
+// pdnLexer splits PDN text into tokens
+type pdnLexer struct {
+	r       *bufio.Reader
+	line    int
+	pending []pdnToken
+}
+
+func (l *pdnLexer) read() (rune, bool) {
+	r, _, err := l.r.ReadRune()
+	if err != nil {
+		return 0, false
+	}
+	if r == '\n' {
+		l.line++
+	}
+	return r, true
+}
+
+func (l *pdnLexer) unread(r rune) {
+	l.r.UnreadRune()
+	if r == '\n' {
+		l.line--
+	}
+}
+
+// readUntil returns everything up to the rune `end`, which is consumed
+func (l *pdnLexer) readUntil(end rune) (string, error) {
+	var sb strings.Builder
+	for {
+		r, ok := l.read()
+		if !ok {
+			return "", fmt.Errorf("Line %d: missing '%c'", l.line, end)
+		}
+		if r == end {
+			return sb.String(), nil
+		}
+		sb.WriteRune(r)
+	}
+}
+
+func (l *pdnLexer) next() (pdnToken, error) {
+	if len(l.pending) > 0 {
+		tok := l.pending[0]
+		l.pending = l.pending[1:]
+		return tok, nil
+	}
+
+	r, ok := l.read()
+	for ok && unicode.IsSpace(r) {
+		r, ok = l.read()
+	}
+	if !ok {
+		return pdnToken{kind: pdnEOF, line: l.line}, nil
+	}
+
+	line := l.line
+	switch r {
+	case '[':
+		body, err := l.readUntil(']')
+		if err != nil {
+			return pdnToken{}, err
+		}
+		return parseTag(body, line)
+	case '{':
+		comment, err := l.readUntil('}')
+		return pdnToken{kind: pdnComment, text: strings.TrimSpace(comment), line: line}, err
+	case ';':
+		comment, _ := l.readUntil('\n')
+		return pdnToken{kind: pdnComment, text: strings.TrimSpace(comment), line: line}, nil
+	case '(':
+		return pdnToken{kind: pdnOpen, line: line}, nil
+	case ')':
+		return pdnToken{kind: pdnClose, line: line}, nil
+	}
+
+	// Anything else is a word running up to whitespace or punctuation
+	var sb strings.Builder
+	for ok && !unicode.IsSpace(r) && !strings.ContainsRune("[]{}();", r) {
+		sb.WriteRune(r)
+		r, ok = l.read()
+	}
+	if ok {
+		l.unread(r)
+	}
+
+	return l.classify(sb.String(), line)
+}
+
+// classify works out what a word is. A move number may run straight into
+// its move, as in 1.11-15, in which case the move is kept for next time.
+func (l *pdnLexer) classify(word string, line int) (pdnToken, error) {
+	if _, ok := pdnResults[word]; ok {
+		return pdnToken{kind: pdnResult, text: word, line: line}, nil
+	}
+
+	if strings.HasPrefix(word, "$") {
+		return pdnToken{kind: pdnNAG, text: word, line: line}, nil
+	}
+
+	if number := pdnNumberPattern.FindString(word); number != "" {
+		if rest := word[len(number):]; rest != "" {
+			tok, err := l.classify(rest, line)
+			if err != nil {
+				return tok, err
+			}
+			l.pending = append(l.pending, tok)
+		}
+		return pdnToken{kind: pdnNumber, text: number, line: line}, nil
+	}
+
+	// Drop any annotation of the move's strength, like ! or ?!
+	move := strings.TrimRight(strings.ToLower(word), "!?")
+	if pdnMovePattern.MatchString(move) {
+		return pdnToken{kind: pdnMoveText, text: move, line: line}, nil
+	}
+
+	return pdnToken{}, fmt.Errorf("Line %d: unexpected '%s'", line, word)
+}
+
+// parseTag splits the inside of [Name "Value"]
+func parseTag(body string, line int) (pdnToken, error) {
+	body = strings.TrimSpace(body)
+	space := strings.IndexFunc(body, unicode.IsSpace)
+	if space < 0 {
+		return pdnToken{}, fmt.Errorf("Line %d: bad tag '%s'", line, body)
+	}
+
+	name := body[:space]
+	quoted := strings.TrimSpace(body[space:])
+	if len(quoted) < 2 || quoted[0] != '"' || quoted[len(quoted)-1] != '"' {
+		return pdnToken{}, fmt.Errorf("Line %d: bad value for tag %s", line, name)
+	}
+
+	var sb strings.Builder
+	escaped := false
+	for _, r := range quoted[1 : len(quoted)-1] {
+		if r == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		sb.WriteRune(r)
+		escaped = false
+	}
+
+	return pdnToken{kind: pdnTag, text: name, value: sb.String(), line: line}, nil
+}
+
+// ParsePDN reads all the games in a PDN file
+func ParsePDN(r io.Reader) ([]*PDNGame, error) {
+	l := &pdnLexer{r: bufio.NewReader(r), line: 1}
+	games := []*PDNGame{}
+
+	tok, err := l.next()
+	for err == nil && tok.kind != pdnEOF {
+		g := &PDNGame{Result: ResultUnknown}
+
+		for err == nil && tok.kind == pdnTag {
+			g.SetTag(tok.text, tok.value)
+			tok, err = l.next()
+		}
+		if err != nil {
+			break
+		}
+
+		g.Moves, tok, err = parseLine(l, tok, 0)
+		if err != nil {
+			break
+		}
+
+		if tok.kind == pdnResult {
+			g.Result = pdnResults[tok.text]
+			tok, err = l.next()
+		}
+
+		games = append(games, g)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return games, nil
+}
+
+// parseLine reads moves starting at `tok` until the end of the line of
+// play: a result, a new game's tags or the end of the file for the main
+// line, or a closing bracket for a variation. It returns the moves, and the
+// token which ended the line.
+func parseLine(l *pdnLexer, tok pdnToken, depth int) ([]*PDNMove, pdnToken, error) {
+	moves := []*PDNMove{}
+	preComment := ""
+	var err error
+
+	for {
+		switch tok.kind {
+		case pdnEOF, pdnTag, pdnResult:
+			if depth > 0 {
+				if tok.kind == pdnResult {
+					break // Some files end variations with a result; skip it
+				}
+				return nil, tok, fmt.Errorf("Line %d: unterminated variation", tok.line)
+			}
+			return moves, tok, nil
+
+		case pdnClose:
+			if depth == 0 {
+				return nil, tok, fmt.Errorf("Line %d: unexpected ')'", tok.line)
+			}
+			return moves, tok, nil
+
+		case pdnOpen:
+			if len(moves) == 0 {
+				return nil, tok, fmt.Errorf("Line %d: variation before any move", tok.line)
+			}
+
+			// A variation with nothing but comments in it is taken as a
+			// comment on the move, and one with nothing at all is an error
+			last := moves[len(moves)-1]
+			comment := ""
+			first, err := l.next()
+			for err == nil && first.kind == pdnComment {
+				comment = strings.TrimSpace(comment + " " + first.text)
+				first, err = l.next()
+			}
+			if err != nil {
+				return nil, tok, err
+			}
+
+			if first.kind == pdnClose && comment != "" {
+				last.Comment = strings.TrimSpace(last.Comment + " " + comment)
+				break
+			}
+
+			variation, end, err := parseLine(l, first, depth+1)
+			if err != nil {
+				return nil, end, err
+			}
+			if len(variation) == 0 {
+				return nil, end, fmt.Errorf("Line %d: empty variation", tok.line)
+			}
+
+			variation[0].PreComment = strings.TrimSpace(comment + " " + variation[0].PreComment)
+			last.Variations = append(last.Variations, variation)
+
+		case pdnComment:
+			if len(moves) == 0 {
+				preComment = strings.TrimSpace(preComment + " " + tok.text)
+			} else {
+				last := moves[len(moves)-1]
+				last.Comment = strings.TrimSpace(last.Comment + " " + tok.text)
+			}
+
+		case pdnMoveText:
+			moves = append(moves, &PDNMove{Text: tok.text, PreComment: preComment})
+			preComment = ""
+
+		case pdnNumber, pdnNAG:
+			// Move numbers are implied by the order of moves
+		}
+
+		tok, err = l.next()
+		if err != nil {
+			return nil, tok, err
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package draughts
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+const samplePDN = `[Event "Sample"]
+[Black "Red \"the bold\""]
+[White "Green"]
+[Result "1/2-1/2"]
+
+{Opening comment} 1. 11-15 23-19 2. 8-11 22-17 {the Old Fourteenth}
+3. 4-8 (3. 9-13 17-14 (3... 24-20) 10x17) 17-13 4.15-18! 1/2-1/2
+
+[Event "Second"]
+1. 9-14 *
+`
+
+func TestParsePDN(t *testing.T) {
+	games, err := ParsePDN(strings.NewReader(samplePDN))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(games) != 2 {
+		t.Fatalf("Expected 2 games, found %d", len(games))
+	}
+
+	g := games[0]
+	if g.Tag("Black") != `Red "the bold"` || g.Tag("Event") != "Sample" {
+		t.Errorf("Unexpected tags %v", g.Tags)
+	}
+
+	if g.Result != ResultDraw {
+		t.Errorf("Expected a draw, found %s", g.Result)
+	}
+
+	moves := []string{}
+	for _, move := range g.Moves {
+		moves = append(moves, move.Text)
+	}
+	if strings.Join(moves, " ") != "11-15 23-19 8-11 22-17 4-8 17-13 15-18" {
+		t.Errorf("Unexpected main line %v", moves)
+	}
+
+	if g.Moves[0].PreComment != "Opening comment" || g.Moves[3].Comment != "the Old Fourteenth" {
+		t.Errorf("Comments not attached to the right moves")
+	}
+
+	variations := g.Moves[4].Variations
+	if len(variations) != 1 || len(variations[0]) != 3 || len(variations[0][1].Variations) != 1 {
+		t.Errorf("Unexpected variations %v", variations)
+	}
+
+	if games[1].Tag("Event") != "Second" || len(games[1].Moves) != 1 || games[1].Result != ResultUnknown {
+		t.Errorf("Second game not read correctly")
+	}
+}
+
+func TestParsePDNErrors(t *testing.T) {
+	bad := []string{
+		`[Event "Unterminated`,
+		`1. 11-15 (23-19`,
+		`1. 11-15 23-19)`,
+		`1. 11-15 {no end`,
+		`1. eleven-15`,
+		`(1. 11-15)`,
+	}
+
+	for _, text := range bad {
+		if _, err := ParsePDN(strings.NewReader(text)); err == nil {
+			t.Errorf("Expected an error parsing '%s'", text)
+		}
+	}
+}
+
+func TestPDNRoundTrip(t *testing.T) {
+	games, err := ParsePDN(strings.NewReader(samplePDN))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WritePDN(&buf, games); err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := ParsePDN(&buf)
+	if err != nil {
+		t.Fatalf("Can't read back what was written: %s", err)
+	}
+
+	for i := range games {
+		if games[i].String() != again[i].String() {
+			t.Errorf("Game %d changed on the way round:\n%s\n%s", i, games[i], again[i])
+		}
+	}
+
+	// Move numbers are repeated after comments and variations
+	text := strings.Join(strings.Fields(games[0].String()), " ")
+	if !strings.Contains(text, "3. 4-8 (3. 9-13 17-14 (3... 24-20) 4. 10x17) 3... 17-13 4. 15-18") {
+		t.Errorf("Variations not written as expected:\n%s", games[0])
+	}
+}
+
+func TestPDNEmptyVariations(t *testing.T) {
+	// An empty variation is an error
+	if _, err := ParsePDN(strings.NewReader("1. 11-15 () 23-19 *")); err == nil {
+		t.Error("Expected an error parsing an empty variation")
+	}
+
+	// One with only a comment is a comment on the move
+	games, err := ParsePDN(strings.NewReader("1. 11-15 ({note}) 23-19 *"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := strings.Join(strings.Fields(games[0].String()), " ")
+	if !strings.HasSuffix(text, "1. 11-15 {note} 1... 23-19 *") {
+		t.Errorf("Expected the comment kept, found:\n%s", games[0])
+	}
+
+	// A game put together with an empty variation is written without it
+	games[0].Moves[0].Variations = [][]*PDNMove{{}}
+	if written := games[0].String(); strings.Contains(written, "(") {
+		t.Errorf("Expected no variation written, found:\n%s", written)
+	}
+}
+
+func TestResolveMove(t *testing.T) {
+	board := boardFromSquares(map[int]int{9: 1, 14: 2, 23: 2})
+
+	// A multi-jump may be given by just its ends
+	for _, text := range []string{"9x27", "9x18x27", "9-18-27"} {
+		move, err := board.ResolveMove(text, 1)
+		if err != nil {
+			t.Errorf("%s: %s", text, err)
+		} else if board.MoveString(move) != "9x18x27" {
+			t.Errorf("%s: resolved to %s", text, board.MoveString(move))
+		}
+	}
+
+	// Captures are compulsory
+	if _, err := board.ResolveMove("9-13", 1); err == nil {
+		t.Errorf("Expected 9-13 to be illegal")
+	}
+
+	// Algebraic squares, with a1 at the bottom left
+	move, err := NewVariantBoard(Russian).ResolveMove("c3-d4", 2)
+	if err != nil || NewVariantBoard(Russian).MoveString(move) != "22-18" {
+		t.Errorf("Expected c3-d4 to be 22-18")
+	}
+}
+
+func TestReplayRandomGames(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for _, v := range Variants {
+		start := NewVariantBoard(v)
+		board := start
+		player := v.FirstPlayer
+		played := []*Move{}
+		for ply := 0; ply < 60; ply++ {
+			moves := board.AllMoves(player)
+			if len(moves) == 0 {
+				break
+			}
+
+			move := moves[rng.Intn(len(moves))]
+			played = append(played, move)
+			board = board.Apply(move)
+			player = Opposition(player)
+		}
+
+		g := NewPDNGame(start, v.FirstPlayer, played)
+		games, err := ParsePDN(strings.NewReader(g.String()))
+		if err != nil {
+			t.Fatalf("%s: %s", v.Name, err)
+		}
+
+		_, replayed, err := games[0].Replay()
+		if err != nil {
+			t.Fatalf("%s: %s\n%s", v.Name, err, g)
+		}
+
+		if len(replayed) != len(played) {
+			t.Fatalf("%s: expected %d moves, found %d", v.Name, len(played), len(replayed))
+		}
+		for i := range played {
+			if !played[i].Equals(replayed[i]) {
+				t.Errorf("%s: move %d differs", v.Name, i)
+			}
+		}
+	}
+}
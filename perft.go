@@ -0,0 +1,60 @@
+package draughts
+
+import "sort"
+
+// Perft counts the positions reached by every sequence of `depth` moves
+// from `b`, with `player` to move. The counts for well-known positions are
+// published, so comparing them is a thorough test of move generation: a
+// capture missed, or a man crowned wrongly, soon changes the numbers.
+func Perft(b *Board, player, depth int) int {
+	board := *b
+	return board.perft(player, depth)
+}
+
+// perft is Perft, making and unmaking the moves on `b`
+func (b *Board) perft(player, depth int) int {
+	if depth == 0 {
+		return 1
+	}
+
+	moves := b.AllMoves(player)
+	if depth == 1 {
+		return len(moves)
+	}
+
+	count := 0
+	for _, move := range moves {
+		undo := b.MakeMove(move)
+		count += b.perft(Opposition(player), depth-1)
+		b.UnmakeMove(undo)
+	}
+
+	return count
+}
+
+// PerftDivision is the positions counted after one of the first moves
+type PerftDivision struct {
+	Move  string // In PDN
+	Count int
+}
+
+// Divide splits the Perft count by the first move, in order of the moves'
+// PDN, so where two move generators disagree, the difference can be chased
+// down move by move
+func Divide(b *Board, player, depth int) []PerftDivision {
+	divisions := []PerftDivision{}
+	if depth == 0 {
+		return divisions
+	}
+
+	board := *b
+	for _, move := range board.AllMoves(player) {
+		text := board.MoveString(move)
+		undo := board.MakeMove(move)
+		divisions = append(divisions, PerftDivision{text, board.perft(Opposition(player), depth-1)})
+		board.UnmakeMove(undo)
+	}
+
+	sort.Slice(divisions, func(i, j int) bool { return divisions[i].Move < divisions[j].Move })
+	return divisions
+}
@@ -0,0 +1,74 @@
+package draughts
+
+import "testing"
+
+func TestEnglishPerft(t *testing.T) {
+	expected := []int{1, 7, 49, 302, 1469, 7361, 36768, 179740, 845931}
+
+	board := NewBoard()
+	for depth, count := range expected {
+		if actual := Perft(board, 1, depth); actual != count {
+			t.Errorf("Depth %d: expected %d positions, found %d", depth, count, actual)
+		}
+	}
+}
+
+func TestDivide(t *testing.T) {
+	board := NewBoard()
+	divisions := Divide(board, 1, 4)
+	if len(divisions) != 7 {
+		t.Fatalf("Expected 7 opening moves, found %d", len(divisions))
+	}
+
+	total := 0
+	for _, d := range divisions {
+		total += d.Count
+	}
+	if total != 1469 {
+		t.Errorf("Expected the divisions to add up to 1469, found %d", total)
+	}
+
+	if d := divisions[0]; d.Move != "10-14" || d.Count != 175 {
+		t.Errorf("Expected 10-14 with 175 first, found %s with %d", d.Move, d.Count)
+	}
+
+	// A double jump is one move, and crowning ends it
+	board, player, err := ParseFEN(English, "B:W18,27:B14")
+	if err != nil {
+		t.Fatal(err)
+	}
+	divisions = Divide(board, player, 1)
+	if len(divisions) != 1 || divisions[0].Move != "14x23x32" {
+		t.Errorf("Expected only 14x23x32, found %v", divisions)
+	}
+}
+
+// perftApply is Perft copying the board with Apply, to compare with
+func perftApply(b *Board, player, depth int) int {
+	if depth == 0 {
+		return 1
+	}
+
+	count := 0
+	for _, move := range b.AllMoves(player) {
+		count += perftApply(b.Apply(move), Opposition(player), depth-1)
+	}
+
+	return count
+}
+
+func BenchmarkPerft(b *testing.B) {
+	board := NewBoard()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Perft(board, 1, 6)
+	}
+}
+
+func BenchmarkPerftApply(b *testing.B) {
+	board := NewBoard()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		perftApply(board, 1, 6)
+	}
+}
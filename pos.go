@@ -1,4 +1,4 @@
-package main
+package draughts
 
 import "fmt"
 
@@ -78,8 +78,19 @@ func abs(v int) int {
 	return v
 }
 
+// sign returns -1, 0 or 1 according to the sign of `v`
+func sign(v int) int {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	}
+	return 0
+}
+
 // ValidDiagonal check that we moved diagonally at least one and max 2
-// squares
+// squares, returning the change in X and Y
 func ValidDiagonal(p1, p2 Pos) (int, int, error) {
 	dX := p2.X - p1.X
 	dY := p2.Y - p1.Y
@@ -97,5 +108,21 @@ func ValidDiagonal(p1, p2 Pos) (int, int, error) {
 		return 0, 0, fmt.Errorf("Incorrect Y for valid diagonal move")
 	}
 
+	// ..and the same number in both, or it isn't a diagonal
+	if adX != adY {
+		return 0, 0, fmt.Errorf("Move is not diagonal")
+	}
+
 	return dX, dY, nil
 }
+
+// containsPos checks if `p` is in the list `squares`
+func containsPos(squares []Pos, p Pos) bool {
+	for _, square := range squares {
+		if square == p {
+			return true
+		}
+	}
+
+	return false
+}
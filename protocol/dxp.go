@@ -0,0 +1,558 @@
+package protocol
+
+// The DamExchange Protocol (DXP) lets two programs play international
+// draughts against each other over TCP. Messages are ASCII, each ended by
+// a NUL byte, with a letter giving the type followed by fixed width fields:
+//
+//	R GAMEREQ  version(2) name(32) follower colour(1) minutes(3) moves(3)
+//	           position(A = initial, or B + colour to move + 50 squares)
+//	A GAMEACC  name(32) accept code(1)
+//	M MOVE     seconds(4) from(2) to(2) captures(2) captured squares(2 each)
+//	E GAMEEND  reason(1) stop code(1)
+//	C CHAT     text
+//	B BACKREQ  move number(3) colour(1)
+//	K BACKACC  accept code(1)
+//
+// Colours are W for White, our player 2, and Z (zwart) for Black, player 1.
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xpqz/draughts"
+)
+
+// dxpVersion is the protocol version we speak
+const dxpVersion = 1
+
+// dxpNameWidth is the width of the name fields
+const dxpNameWidth = 32
+
+// Accept codes, for GAMEACC and BACKACC
+const (
+	DXPAccept      = 0
+	DXPDecline     = 1
+	DXPUnsupported = 2
+)
+
+// GAMEEND reasons, from the point of view of the sender
+const (
+	DXPUnknown = 0
+	DXPILose   = 1
+	DXPDraw    = 2
+	DXPIWin    = 3
+)
+
+// DXPMessage is any of the messages below
+type DXPMessage interface {
+	Encode() string
+}
+
+// DXPGameRequest asks the follower for a game
+type DXPGameRequest struct {
+	Name           string
+	FollowerPlayer int // 1 for Black, 2 for White
+	Minutes        int // Thinking time for Moves moves
+	Moves          int
+	Board          *draughts.Board // nil for the initial position
+	Player         int             // To move, if Board is set
+}
+
+// DXPGameAccept answers a game request
+type DXPGameAccept struct {
+	Name string
+	Code int
+}
+
+// DXPMove is a move, given by its start and end squares and those it
+// captured, which is enough to tell apart any two legal moves
+type DXPMove struct {
+	Seconds  int
+	From, To int
+	Captured []int
+}
+
+// DXPGameEnd ends a game. Stop is true if the sender wants no more games.
+type DXPGameEnd struct {
+	Reason int
+	Stop   bool
+}
+
+// DXPChat is a message for the humans watching
+type DXPChat struct {
+	Text string
+}
+
+// DXPBackRequest asks to take moves back, to the given move number with
+// the given player to move
+type DXPBackRequest struct {
+	Move   int
+	Player int
+}
+
+// DXPBackAccept answers a back request
+type DXPBackAccept struct {
+	Code int
+}
+
+// dxpColour is the DXP colour letter for `player`
+func dxpColour(player int) string {
+	if player == 2 {
+		return "W"
+	}
+	return "Z"
+}
+
+// dxpPlayer is the player for DXP colour letter `colour`
+func dxpPlayer(colour byte) (int, error) {
+	switch colour {
+	case 'W':
+		return 2, nil
+	case 'Z':
+		return 1, nil
+	}
+
+	return 0, fmt.Errorf("Bad colour '%c'", colour)
+}
+
+// dxpPieces are the letters for each piece in a GAMEREQ position, indexed
+// by the piece value + 2: kings are capitals, z (zwart) is Black and w is
+// White
+var dxpPieces = "WZezw"
+
+// Encode formats the message, without the terminating NUL
+func (m *DXPGameRequest) Encode() string {
+	position := "A"
+	if m.Board != nil {
+		v := m.Board.Variant()
+		squares := make([]byte, v.SquareCount())
+		for square := range squares {
+			squares[square] = dxpPieces[m.Board.Get(v.SquarePos(square+1))+2]
+		}
+		position = "B" + dxpColour(m.Player) + string(squares)
+	}
+
+	return fmt.Sprintf("R%02d%-*.*s%s%03d%03d%s", dxpVersion, dxpNameWidth, dxpNameWidth,
+		m.Name, dxpColour(m.FollowerPlayer), m.Minutes, m.Moves, position)
+}
+
+// Encode formats the message, without the terminating NUL
+func (m *DXPGameAccept) Encode() string {
+	return fmt.Sprintf("A%-*.*s%d", dxpNameWidth, dxpNameWidth, m.Name, m.Code)
+}
+
+// Encode formats the message, without the terminating NUL
+func (m *DXPMove) Encode() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "M%04d%02d%02d%02d", m.Seconds, m.From, m.To, len(m.Captured))
+	for _, square := range m.Captured {
+		fmt.Fprintf(&sb, "%02d", square)
+	}
+
+	return sb.String()
+}
+
+// Encode formats the message, without the terminating NUL
+func (m *DXPGameEnd) Encode() string {
+	stop := 0
+	if m.Stop {
+		stop = 1
+	}
+	return fmt.Sprintf("E%d%d", m.Reason, stop)
+}
+
+// Encode formats the message, without the terminating NUL
+func (m *DXPChat) Encode() string {
+	return "C" + m.Text
+}
+
+// Encode formats the message, without the terminating NUL
+func (m *DXPBackRequest) Encode() string {
+	return fmt.Sprintf("B%03d%s", m.Move, dxpColour(m.Player))
+}
+
+// Encode formats the message, without the terminating NUL
+func (m *DXPBackAccept) Encode() string {
+	return fmt.Sprintf("K%d", m.Code)
+}
+
+// dxpFields reads fixed width numeric fields from the start of `text`
+func dxpFields(text string, widths ...int) ([]int, error) {
+	values := []int{}
+	for _, width := range widths {
+		if len(text) < width {
+			return nil, fmt.Errorf("Message too short")
+		}
+
+		value, err := strconv.Atoi(text[:width])
+		if err != nil {
+			return nil, fmt.Errorf("Bad number '%s'", text[:width])
+		}
+		values = append(values, value)
+		text = text[width:]
+	}
+
+	return values, nil
+}
+
+// ParseDXP reads a message, without its terminating NUL
+func ParseDXP(msg string) (DXPMessage, error) {
+	if msg == "" {
+		return nil, fmt.Errorf("Empty message")
+	}
+
+	body := msg[1:]
+	switch msg[0] {
+	case 'R':
+		req, err := parseGameRequest(body)
+		if err != nil {
+			return nil, err
+		}
+		return req, nil
+
+	case 'A':
+		if len(body) != dxpNameWidth+1 {
+			return nil, fmt.Errorf("Bad GAMEACC '%s'", msg)
+		}
+		fields, err := dxpFields(body[dxpNameWidth:], 1)
+		if err != nil {
+			return nil, err
+		}
+		return &DXPGameAccept{strings.TrimSpace(body[:dxpNameWidth]), fields[0]}, nil
+
+	case 'M':
+		fields, err := dxpFields(body, 4, 2, 2, 2)
+		if err != nil {
+			return nil, err
+		}
+		m := &DXPMove{Seconds: fields[0], From: fields[1], To: fields[2]}
+		widths := make([]int, fields[3])
+		for i := range widths {
+			widths[i] = 2
+		}
+		if len(body) != 10+2*fields[3] {
+			return nil, fmt.Errorf("Bad MOVE '%s'", msg)
+		}
+		if m.Captured, err = dxpFields(body[10:], widths...); err != nil {
+			return nil, err
+		}
+		return m, nil
+
+	case 'E':
+		fields, err := dxpFields(body, 1, 1)
+		if err != nil {
+			return nil, err
+		}
+		return &DXPGameEnd{fields[0], fields[1] == 1}, nil
+
+	case 'C':
+		return &DXPChat{body}, nil
+
+	case 'B':
+		fields, err := dxpFields(body, 3)
+		if err != nil || len(body) != 4 {
+			return nil, fmt.Errorf("Bad BACKREQ '%s'", msg)
+		}
+		player, err := dxpPlayer(body[3])
+		if err != nil {
+			return nil, err
+		}
+		return &DXPBackRequest{fields[0], player}, nil
+
+	case 'K':
+		fields, err := dxpFields(body, 1)
+		if err != nil {
+			return nil, err
+		}
+		return &DXPBackAccept{fields[0]}, nil
+	}
+
+	return nil, fmt.Errorf("Unknown message type '%c'", msg[0])
+}
+
+// parseGameRequest reads the body of a GAMEREQ
+func parseGameRequest(body string) (*DXPGameRequest, error) {
+	if len(body) < 2+dxpNameWidth+1+3+3+1 {
+		return nil, fmt.Errorf("Bad GAMEREQ 'R%s'", body)
+	}
+
+	m := &DXPGameRequest{Name: strings.TrimSpace(body[2 : 2+dxpNameWidth])}
+	rest := body[2+dxpNameWidth:]
+
+	var err error
+	if m.FollowerPlayer, err = dxpPlayer(rest[0]); err != nil {
+		return nil, err
+	}
+
+	fields, err := dxpFields(rest[1:], 3, 3)
+	if err != nil {
+		return nil, err
+	}
+	m.Minutes, m.Moves = fields[0], fields[1]
+
+	position := rest[7:]
+	switch {
+	case position == "A":
+		return m, nil
+	case len(position) != 2+draughts.International.SquareCount() || position[0] != 'B':
+		return nil, fmt.Errorf("Bad position in GAMEREQ")
+	}
+
+	if m.Player, err = dxpPlayer(position[1]); err != nil {
+		return nil, err
+	}
+
+	m.Board = draughts.NewVariantBoard(draughts.International)
+	for square, letter := range position[2:] {
+		piece := strings.IndexRune(dxpPieces, letter) - 2
+		if piece < -2 {
+			return nil, fmt.Errorf("Bad piece '%c' in GAMEREQ", letter)
+		}
+		m.Board.Set(draughts.International.SquarePos(square+1), piece)
+	}
+
+	return m, nil
+}
+
+// DXPConn sends and receives DXP messages over a connection
+type DXPConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// NewDXPConn is the constructor
+func NewDXPConn(conn net.Conn) *DXPConn {
+	return &DXPConn{conn, bufio.NewReader(conn)}
+}
+
+// Send writes a message
+func (c *DXPConn) Send(m DXPMessage) error {
+	_, err := c.conn.Write([]byte(m.Encode() + "\x00"))
+	return err
+}
+
+// Receive waits for the next message
+func (c *DXPConn) Receive() (DXPMessage, error) {
+	msg, err := c.r.ReadString(0)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseDXP(strings.TrimSuffix(msg, "\x00"))
+}
+
+// DXPPlayer plays games of international draughts over DXP with the engine
+type DXPPlayer struct {
+	Name   string
+	Engine *draughts.Engine
+
+	// Budget is the thinking time per move if the game request doesn't
+	// give one
+	Budget time.Duration
+}
+
+// NewDXPPlayer is the constructor
+func NewDXPPlayer(name string) *DXPPlayer {
+	return &DXPPlayer{
+		Name:   name,
+		Engine: draughts.NewEngine(draughts.DefaultTableSize),
+		Budget: 2 * time.Second,
+	}
+}
+
+// Follow waits for game requests on `conn` and plays them, until the
+// other side asks to stop or hangs up
+func (p *DXPPlayer) Follow(conn net.Conn) error {
+	c := NewDXPConn(conn)
+	for {
+		m, err := c.Receive()
+		if err != nil {
+			return err
+		}
+
+		req, ok := m.(*DXPGameRequest)
+		if !ok {
+			continue // Nothing else means anything outside a game
+		}
+
+		if err := c.Send(&DXPGameAccept{p.Name, DXPAccept}); err != nil {
+			return err
+		}
+
+		stop, err := p.play(c, req, req.FollowerPlayer)
+		if err != nil || stop {
+			return err
+		}
+	}
+}
+
+// Initiate sends the game request `req` on `conn` and, if it's accepted,
+// plays the game, the engine taking the side the follower doesn't. Name is
+// filled in with the player's.
+func (p *DXPPlayer) Initiate(conn net.Conn, req *DXPGameRequest) error {
+	c := NewDXPConn(conn)
+	req.Name = p.Name
+	if err := c.Send(req); err != nil {
+		return err
+	}
+
+	m, err := c.Receive()
+	if err != nil {
+		return err
+	}
+	if acc, ok := m.(*DXPGameAccept); !ok || acc.Code != DXPAccept {
+		return fmt.Errorf("Game request declined")
+	}
+
+	_, err = p.play(c, req, draughts.Opposition(req.FollowerPlayer))
+	return err
+}
+
+// budget is the thinking time per move for the game requested by `req`
+func (p *DXPPlayer) budget(req *DXPGameRequest) time.Duration {
+	if req.Minutes > 0 && req.Moves > 0 {
+		return time.Duration(req.Minutes) * time.Minute / time.Duration(req.Moves)
+	}
+	return p.Budget
+}
+
+// play plays out the game requested by `req`, the engine being `us`. The
+// game ends by International's rules, draws included. It returns true if
+// the other side wants no more games.
+func (p *DXPPlayer) play(c *DXPConn, req *DXPGameRequest, us int) (bool, error) {
+	game := draughts.NewGame(draughts.International)
+	if req.Board != nil {
+		game = draughts.NewGameFromPosition(req.Board, req.Player)
+	}
+
+	for {
+		// Whoever made the last move announces the end of the game, and the
+		// other side agrees
+		if game.Over() && game.Player() != us {
+			return p.endGame(c, game, us)
+		}
+
+		if game.Player() == us && !game.Over() {
+			start := time.Now()
+			score := p.Engine.Search(game.Board(), us, draughts.SearchLimits{Budget: p.budget(req)})
+			m := dxpMove(game.Board(), score.Move)
+			m.Seconds = int(time.Since(start).Seconds())
+			if err := c.Send(m); err != nil {
+				return false, err
+			}
+			game.Play(score.Move)
+			continue
+		}
+
+		m, err := c.Receive()
+		if err != nil {
+			return false, err
+		}
+
+		switch m := m.(type) {
+		case *DXPMove:
+			move, err := resolveDXPMove(game, m)
+			if err == nil {
+				err = game.Play(move)
+			}
+			if err != nil {
+				c.Send(&DXPGameEnd{DXPUnknown, true})
+				return true, fmt.Errorf("Opponent's move %s: %s", m.Encode(), err)
+			}
+
+		case *DXPGameEnd:
+			// Agree with their view of the result
+			reason := m.Reason
+			if reason == DXPILose || reason == DXPIWin {
+				reason = DXPIWin + DXPILose - reason
+			}
+			return m.Stop, c.Send(&DXPGameEnd{reason, m.Stop})
+
+		case *DXPBackRequest:
+			code := DXPAccept
+			if err := game.GoTo(dxpPly(game, m)); err != nil {
+				code = DXPDecline
+			}
+			if err := c.Send(&DXPBackAccept{code}); err != nil {
+				return false, err
+			}
+		}
+	}
+}
+
+// endGame tells the other side the game is over, and waits for them to
+// agree
+func (p *DXPPlayer) endGame(c *DXPConn, game *draughts.Game, us int) (bool, error) {
+	reason := DXPDraw
+	switch game.Result() {
+	case draughts.WinResult(us):
+		reason = DXPIWin
+	case draughts.WinResult(draughts.Opposition(us)):
+		reason = DXPILose
+	}
+
+	if err := c.Send(&DXPGameEnd{reason, false}); err != nil {
+		return false, err
+	}
+
+	for {
+		m, err := c.Receive()
+		if err != nil {
+			return false, err
+		}
+		if end, ok := m.(*DXPGameEnd); ok {
+			return end.Stop, nil
+		}
+	}
+}
+
+// dxpPly is the ply of the position a back request asks for
+func dxpPly(game *draughts.Game, m *DXPBackRequest) int {
+	_, startPlayer := game.Start()
+	ply := 2 * (m.Move - 1)
+	if m.Player != draughts.International.FirstPlayer {
+		ply++
+	}
+	if startPlayer != draughts.International.FirstPlayer {
+		ply--
+	}
+
+	return ply
+}
+
+// dxpMove describes `move` as a DXP message
+func dxpMove(board *draughts.Board, move *draughts.Move) *DXPMove {
+	v := board.Variant()
+	m := &DXPMove{
+		From:     v.SquareNumber(move.Squares[0]),
+		To:       v.SquareNumber(move.Squares[move.Length()-1]),
+		Captured: []int{},
+	}
+	for _, p := range board.CapturedSquares(move) {
+		m.Captured = append(m.Captured, v.SquareNumber(p))
+	}
+
+	return m
+}
+
+// resolveDXPMove finds the legal move matching a DXP move message
+func resolveDXPMove(game *draughts.Game, m *DXPMove) (*draughts.Move, error) {
+	captured := append([]int{}, m.Captured...)
+	sort.Ints(captured)
+
+	for _, move := range game.LegalMoves() {
+		candidate := dxpMove(game.Board(), move)
+		sort.Ints(candidate.Captured)
+		if candidate.From == m.From && candidate.To == m.To &&
+			fmt.Sprint(candidate.Captured) == fmt.Sprint(captured) {
+			return move, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Illegal move")
+}
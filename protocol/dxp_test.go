@@ -0,0 +1,210 @@
+package protocol
+
+import (
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xpqz/draughts"
+)
+
+func TestDXPMessages(t *testing.T) {
+	board, player, err := draughts.ParseFEN(draughts.International, "W:W31,K46:B5,20")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := []DXPMessage{
+		&DXPGameRequest{Name: "Tester", FollowerPlayer: 1, Minutes: 10, Moves: 50},
+		&DXPGameRequest{Name: "Tester", FollowerPlayer: 2, Board: board, Player: player},
+		&DXPGameAccept{"Follower", DXPAccept},
+		&DXPMove{Seconds: 12, From: 32, To: 28, Captured: []int{}},
+		&DXPMove{From: 46, To: 14, Captured: []int{37, 23}},
+		&DXPGameEnd{DXPIWin, true},
+		&DXPChat{"good luck"},
+		&DXPBackRequest{12, 1},
+		&DXPBackAccept{DXPDecline},
+	}
+
+	for _, m := range messages {
+		parsed, err := ParseDXP(m.Encode())
+		if err != nil {
+			t.Errorf("%s: %s", m.Encode(), err)
+			continue
+		}
+
+		if parsed.Encode() != m.Encode() {
+			t.Errorf("%s came back as %s", m.Encode(), parsed.Encode())
+		}
+	}
+
+	// The encoding is as the protocol describes
+	expected := "R01Tester                          Z010050A"
+	if encoded := messages[0].Encode(); encoded != expected {
+		t.Errorf("Expected '%s', found '%s'", expected, encoded)
+	}
+
+	if encoded := messages[4].Encode(); encoded != "M00004614023723" {
+		t.Errorf("Unexpected move encoding '%s'", encoded)
+	}
+
+	parsed, _ := ParseDXP(messages[1].Encode())
+	if req := parsed.(*DXPGameRequest); req.Board.FEN(req.Player) != board.FEN(player) {
+		t.Errorf("Position didn't survive: %s", req.Board.FEN(req.Player))
+	}
+}
+
+func TestDXPPosition(t *testing.T) {
+	board, player, err := draughts.ParseFEN(draughts.International, "W:W31,K46:B5,K20")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Black's man on 5 and king on 20, White's man on 31 and king on 46
+	squares := []byte(strings.Repeat("e", 50))
+	squares[4], squares[19], squares[30], squares[45] = 'z', 'Z', 'w', 'W'
+	wire := "R01Tester                          Z000000BW" + string(squares)
+
+	req := &DXPGameRequest{Name: "Tester", FollowerPlayer: 1, Board: board, Player: player}
+	if encoded := req.Encode(); encoded != wire {
+		t.Errorf("Expected '%s', found '%s'", wire, encoded)
+	}
+
+	parsed, err := ParseDXP(wire)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req := parsed.(*DXPGameRequest); req.Board.FEN(req.Player) != board.FEN(player) {
+		t.Errorf("Expected %s, found %s", board.FEN(player), req.Board.FEN(req.Player))
+	}
+}
+
+func TestDXPBadMessages(t *testing.T) {
+	bad := []string{
+		"",
+		"X",
+		"R01short",
+		"M000132",
+		"M0001322801",
+		"E",
+		"B01W",
+		"B012Q",
+		"Aname",
+	}
+
+	for _, msg := range bad {
+		if _, err := ParseDXP(msg); err == nil {
+			t.Errorf("Expected an error parsing '%s'", msg)
+		}
+	}
+}
+
+func TestDXPGame(t *testing.T) {
+	// Two engines play out an endgame against each other
+	board, player, err := draughts.ParseFEN(draughts.International, "W:W28,33,K46:B3,9,14")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	initiator, follower := NewDXPPlayer("Initiator"), NewDXPPlayer("Follower")
+	initiator.Budget, follower.Budget = 2*time.Millisecond, 2*time.Millisecond
+
+	a, b := net.Pipe()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- follower.Follow(b)
+	}()
+
+	req := &DXPGameRequest{FollowerPlayer: 1, Board: board, Player: player}
+	if err := initiator.Initiate(a, req); err != nil {
+		t.Fatal(err)
+	}
+	a.Close()
+
+	// The follower waits for another game until we hang up
+	if err := <-errs; err != io.EOF {
+		t.Errorf("Unexpected error from the follower: %v", err)
+	}
+}
+
+func TestDXPInternationalDraws(t *testing.T) {
+	// Two kings against one is drawn after 5 moves each. We play the two
+	// kings, keeping out of reach, and make the tenth move, after which the
+	// engine has to wait for us to end the game rather than play on.
+	board, player, err := draughts.ParseFEN(draughts.International, "B:WK1,K50:BK25")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, b := net.Pipe()
+	defer a.Close()
+	errs := make(chan error, 1)
+	go func() {
+		follower := NewDXPPlayer("Follower")
+		follower.Budget = 2 * time.Millisecond
+		errs <- follower.Follow(b)
+	}()
+
+	c := NewDXPConn(a)
+	c.Send(&DXPGameRequest{Name: "Tester", FollowerPlayer: 1, Board: board, Player: player})
+	if m, err := c.Receive(); err != nil || !reflect.DeepEqual(m, &DXPGameAccept{"Follower", DXPAccept}) {
+		t.Fatalf("Expected the game to be accepted, found %v (%v)", m, err)
+	}
+
+	game := draughts.NewGameFromPosition(board, player)
+	for game.Ply() < 10 {
+		m, err := c.Receive()
+		if err != nil {
+			t.Fatal(err)
+		}
+		move, err := resolveDXPMove(game, m.(*DXPMove))
+		if err != nil {
+			t.Fatal(err)
+		}
+		game.Play(move)
+
+		// Any move that leaves the engine nothing to take
+		moves := game.LegalMoves()
+		reply := moves[0]
+		for _, move := range moves {
+			next := game.Board().Apply(move)
+			if replies := next.AllMoves(1); len(replies) > 0 && !next.IsCapture(replies[0]) {
+				reply = move
+			}
+		}
+		c.Send(dxpMove(game.Board(), reply))
+		game.Play(reply)
+	}
+
+	if game.DrawReason() != draughts.DrawEndgame {
+		t.Fatalf("Expected the game to be drawn, found '%s'", game.DrawReason())
+	}
+	c.Send(&DXPGameEnd{DXPDraw, true})
+	if m, err := c.Receive(); err != nil || !reflect.DeepEqual(m, &DXPGameEnd{DXPDraw, true}) {
+		t.Errorf("Expected the engine to agree to the draw, found %v (%v)", m, err)
+	}
+	if err := <-errs; err != nil {
+		t.Errorf("Unexpected error from the follower: %v", err)
+	}
+}
+
+func TestDXPResolveMove(t *testing.T) {
+	board, player, _ := draughts.ParseFEN(draughts.International, "W:WK46:B37,23")
+	game := draughts.NewGameFromPosition(board, player)
+
+	move, err := resolveDXPMove(game, &DXPMove{From: 46, To: 14, Captured: []int{23, 37}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(dxpMove(board, move).Captured, []int{37, 23}) {
+		t.Errorf("Resolved the wrong move: %s", board.MoveString(move))
+	}
+
+	if _, err := resolveDXPMove(game, &DXPMove{From: 46, To: 41}); err == nil {
+		t.Errorf("Expected an error for an illegal move")
+	}
+}
@@ -0,0 +1,347 @@
+// Package protocol lets programs other than our own terminal game drive
+// the engine: GUIs, over a line based text protocol on stdin and stdout
+// modelled on chess's UCI, and other draughts programs, over the
+// DamExchange Protocol (DXP) on TCP.
+package protocol
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xpqz/draughts"
+)
+
+// The text protocol is UCI with draughts moves and positions:
+//
+//	uci                            -> id name ..., id author ..., option ..., uciok
+//	isready                        -> readyok
+//	setoption name Variant value russian
+//	setoption name Weights value weights.toml   evaluation weights, see draughts.ParseWeights
+//	setoption name Threads value 8 search on this many goroutines
+//	ucinewgame                     forget everything learned so far
+//	position startpos [moves 11-15 23-19 ...]
+//	position fen W:W21,22:B1,2 [moves ...]
+//	go [depth n] [movetime ms] [wtime ms] [btime ms] [winc ms] [binc ms]
+//	   [movestogo n] [infinite]    -> info ..., bestmove 11-15
+//	stop                           stop searching and answer at once
+//	quit
+//
+// Moves are in PDN, positions in PDN-FEN. As in PDN, White is player 2.
+
+// maxThreads is the most threads the Threads option allows
+const maxThreads = 256
+
+// winThreshold is the value beyond which a score is a forced win
+const winThreshold = draughts.WinScore - 1000
+
+// TextEngine plays the engine's side of the text protocol
+type TextEngine struct {
+	Name, Author string
+
+	out     io.Writer
+	outLock sync.Mutex // Searches report from their own goroutine
+
+	engine  *draughts.Engine
+	variant *draughts.Variant
+	board   *draughts.Board
+	player  int
+
+	stop chan struct{} // Closed to stop the search in progress
+	done chan struct{} // Closed once the search in progress has answered
+}
+
+// NewTextEngine is the constructor. Replies are written to `out`.
+func NewTextEngine(out io.Writer) *TextEngine {
+	te := &TextEngine{
+		Name:    "draughts",
+		Author:  "xpqz",
+		out:     out,
+		engine:  draughts.NewEngine(draughts.DefaultTableSize),
+		variant: draughts.English,
+	}
+	te.board, te.player = draughts.NewVariantBoard(te.variant), te.variant.FirstPlayer
+
+	return te
+}
+
+// send writes a line of output
+func (te *TextEngine) send(format string, args ...interface{}) {
+	te.outLock.Lock()
+	defer te.outLock.Unlock()
+	fmt.Fprintf(te.out, format+"\n", args...)
+}
+
+// Run reads and handles commands from `in` until told to quit, or the
+// input runs out
+func (te *TextEngine) Run(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if !te.Handle(scanner.Text()) {
+			return nil
+		}
+	}
+
+	te.stopSearch()
+	return scanner.Err()
+}
+
+// Handle carries out a single command, returning false if it was quit.
+// Mistakes are reported back as info strings, as a GUI would expect.
+func (te *TextEngine) Handle(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return true
+	}
+
+	var err error
+	switch fields[0] {
+	case "uci":
+		te.send("id name %s", te.Name)
+		te.send("id author %s", te.Author)
+		names := []string{}
+		for _, v := range draughts.Variants {
+			names = append(names, "var "+v.Name)
+		}
+		te.send("option name Variant type combo default english %s", strings.Join(names, " "))
+		te.send("option name Weights type string default <empty>")
+		te.send("option name Threads type spin default 1 min 1 max %d", maxThreads)
+		te.send("uciok")
+	case "isready":
+		te.send("readyok")
+	case "setoption":
+		err = te.setOption(fields[1:])
+	case "ucinewgame":
+		te.stopSearch()
+		evaluator, threads := te.engine.Evaluator, te.engine.Threads
+		te.engine = draughts.NewEngine(draughts.DefaultTableSize)
+		te.engine.Evaluator, te.engine.Threads = evaluator, threads
+	case "position":
+		te.stopSearch()
+		err = te.position(fields[1:])
+	case "go":
+		te.stopSearch()
+		err = te.goSearch(fields[1:])
+	case "stop":
+		te.stopSearch()
+	case "quit":
+		te.stopSearch()
+		return false
+	default:
+		err = fmt.Errorf("Unknown command '%s'", fields[0])
+	}
+
+	if err != nil {
+		te.send("info string %s", err)
+	}
+
+	return true
+}
+
+// setOption handles "setoption name <name> value <value>". The value is
+// the rest of the line, so it may have spaces in it, as paths can.
+func (te *TextEngine) setOption(args []string) error {
+	if len(args) < 4 || args[0] != "name" || args[2] != "value" {
+		return fmt.Errorf("Expected setoption name <name> value <value>")
+	}
+	value := strings.Join(args[3:], " ")
+
+	switch {
+	case strings.EqualFold(args[1], "Variant"):
+		v, err := draughts.VariantByName(value)
+		if err != nil {
+			return err
+		}
+
+		te.stopSearch()
+		te.variant = v
+		te.board, te.player = draughts.NewVariantBoard(v), v.FirstPlayer
+	case strings.EqualFold(args[1], "Weights"):
+		data, err := os.ReadFile(value)
+		if err != nil {
+			return err
+		}
+		weights, err := draughts.ParseWeights(data)
+		if err != nil {
+			return err
+		}
+
+		te.stopSearch()
+		te.engine.Evaluator = weights
+	case strings.EqualFold(args[1], "Threads"):
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxThreads {
+			return fmt.Errorf("Threads must be 1 to %d", maxThreads)
+		}
+
+		te.stopSearch()
+		te.engine.Threads = n
+	default:
+		return fmt.Errorf("Unknown option '%s'", args[1])
+	}
+
+	return nil
+}
+
+// position sets up the board from "startpos" or "fen <fen>", followed by
+// any moves played since
+func (te *TextEngine) position(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("Expected startpos or fen")
+	}
+
+	board, player := draughts.NewVariantBoard(te.variant), te.variant.FirstPlayer
+	switch {
+	case args[0] == "startpos":
+		args = args[1:]
+	case args[0] == "fen" && len(args) > 1:
+		var err error
+		board, player, err = draughts.ParseFEN(te.variant, args[1])
+		if err != nil {
+			return err
+		}
+		args = args[2:]
+	default:
+		return fmt.Errorf("Expected startpos or fen")
+	}
+
+	if len(args) > 0 {
+		if args[0] != "moves" {
+			return fmt.Errorf("Expected moves, found '%s'", args[0])
+		}
+
+		for _, text := range args[1:] {
+			move, err := board.ResolveMove(text, player)
+			if err != nil {
+				return fmt.Errorf("%s: %s", text, err)
+			}
+			board = board.Apply(move)
+			player = draughts.Opposition(player)
+		}
+	}
+
+	te.board, te.player = board, player
+	return nil
+}
+
+// searchLimits works out the limits from the arguments to "go"
+func (te *TextEngine) searchLimits(args []string) (draughts.SearchLimits, error) {
+	limits := draughts.SearchLimits{}
+	values := map[string]int{"movestogo": draughts.DefaultMovesToGo}
+	for i := 0; i < len(args); i++ {
+		if args[i] == "infinite" {
+			continue
+		}
+
+		if i+1 == len(args) {
+			return limits, fmt.Errorf("Missing value for %s", args[i])
+		}
+
+		value, err := strconv.Atoi(args[i+1])
+		if err != nil || value < 0 {
+			return limits, fmt.Errorf("Bad value for %s: '%s'", args[i], args[i+1])
+		}
+		values[args[i]] = value
+		i++
+	}
+
+	limits.Depth = values["depth"]
+	if movetime, ok := values["movetime"]; ok {
+		limits.Budget = time.Duration(movetime) * time.Millisecond
+	}
+
+	// With a clock, spread what's left over the moves to go
+	clock, increment := "btime", "binc"
+	if te.player == 2 {
+		clock, increment = "wtime", "winc"
+	}
+	if left, ok := values[clock]; ok && limits.Budget == 0 {
+		limits.Budget = draughts.MoveBudget(time.Duration(left)*time.Millisecond,
+			time.Duration(values[increment])*time.Millisecond, values["movestogo"])
+	}
+
+	return limits, nil
+}
+
+// goSearch starts searching the current position. The answer is sent once
+// the search completes, or is stopped. An infinite search's answer waits
+// for "stop" even if the search completes first, as the protocol says.
+func (te *TextEngine) goSearch(args []string) error {
+	limits, err := te.searchLimits(args)
+	if err != nil {
+		return err
+	}
+
+	infinite := false
+	for _, arg := range args {
+		infinite = infinite || arg == "infinite"
+	}
+
+	board, player := te.board, te.player
+	te.stop, te.done = make(chan struct{}), make(chan struct{})
+	limits.Stop = te.stop
+	limits.Report = func(score *draughts.Score) {
+		te.send("info depth %d score %s nodes %d pv %s", score.Depth, scoreString(score.Value),
+			score.Stats.Nodes, lineString(board, score.PV))
+	}
+
+	go func(stop, done chan struct{}) {
+		defer close(done)
+		score := te.engine.Search(board, player, limits)
+		if infinite {
+			<-stop
+		}
+
+		if score.Move == nil {
+			te.send("bestmove (none)")
+			return
+		}
+		te.send("bestmove %s", board.MoveString(score.Move))
+	}(te.stop, te.done)
+
+	return nil
+}
+
+// stopSearch stops any search in progress and waits for its answer
+func (te *TextEngine) stopSearch() {
+	if te.done == nil {
+		return
+	}
+
+	select {
+	case <-te.stop:
+	default:
+		close(te.stop)
+	}
+	<-te.done
+	te.stop, te.done = nil, nil
+}
+
+// scoreString gives a score as "cp <value>", or "win <plies>" for a forced
+// win, negative if it's a loss
+func scoreString(value int) string {
+	switch {
+	case value >= winThreshold:
+		return fmt.Sprintf("win %d", draughts.WinScore-value)
+	case value <= -winThreshold:
+		return fmt.Sprintf("win -%d", draughts.WinScore+value)
+	}
+
+	return fmt.Sprintf("cp %d", value)
+}
+
+// lineString writes a line of play in PDN, starting from `board`
+func lineString(board *draughts.Board, line []*draughts.Move) string {
+	moves := []string{}
+	for _, move := range line {
+		moves = append(moves, board.MoveString(move))
+		board = board.Apply(move)
+	}
+
+	return strings.Join(moves, " ")
+}
@@ -0,0 +1,138 @@
+package protocol
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// textSession runs `commands` through a TextEngine, waiting for each
+// search to finish, and returns the replies
+func textSession(commands ...string) string {
+	var out bytes.Buffer
+	te := NewTextEngine(&out)
+	for _, command := range commands {
+		te.Handle(command)
+		if te.done != nil {
+			<-te.done
+		}
+	}
+	te.Handle("quit")
+
+	return out.String()
+}
+
+func TestTextHandshake(t *testing.T) {
+	out := textSession("uci", "isready")
+	for _, expected := range []string{"id name draughts", "option name Variant", "uciok", "readyok"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected '%s' in:\n%s", expected, out)
+		}
+	}
+}
+
+func TestTextSearch(t *testing.T) {
+	out := textSession("position startpos moves 11-15 23-19", "go depth 3")
+	if !strings.Contains(out, "info depth 3 score cp") {
+		t.Errorf("Expected progress reports in:\n%s", out)
+	}
+	if !strings.Contains(out, "bestmove ") {
+		t.Errorf("Expected a best move in:\n%s", out)
+	}
+
+	// A forced capture is the only move
+	out = textSession("position fen B:W14:B9,1", "go depth 2")
+	if !strings.Contains(out, "bestmove 9x18") {
+		t.Errorf("Expected the capture 9x18 in:\n%s", out)
+	}
+
+	out = textSession("position fen B:W18:B", "go depth 2")
+	if !strings.Contains(out, "bestmove (none)") {
+		t.Errorf("Expected no move in:\n%s", out)
+	}
+}
+
+func TestTextStop(t *testing.T) {
+	var out bytes.Buffer
+	te := NewTextEngine(&out)
+	te.Handle("position startpos")
+	te.Handle("go infinite")
+	te.Handle("stop")
+
+	if !strings.Contains(out.String(), "bestmove ") {
+		t.Errorf("Expected a best move once stopped, found:\n%s", out.String())
+	}
+
+	// A search with only one move to look at ends at once, but its answer
+	// still waits for stop
+	out.Reset()
+	te.Handle("position fen B:W14:B9,1")
+	te.Handle("go infinite")
+	select {
+	case <-te.done:
+		t.Errorf("Expected the best move to wait for stop")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	te.Handle("stop")
+	if !strings.Contains(out.String(), "bestmove 9x18") {
+		t.Errorf("Expected the capture 9x18 once stopped, found:\n%s", out.String())
+	}
+}
+
+func TestTextVariantAndClock(t *testing.T) {
+	out := textSession("setoption name Variant value international", "position startpos",
+		"go wtime 1000 btime 1000")
+	if !strings.Contains(out, "bestmove 3") { // International's first moves are from 31-35
+		t.Errorf("Expected an international opening move in:\n%s", out)
+	}
+}
+
+func TestTextWeights(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "my weights.toml")
+	os.WriteFile(path, []byte("piece = 1000\n"), 0644)
+
+	// A piece up, by a lot more than usual
+	out := textSession("setoption name Weights value "+path, "ucinewgame",
+		"position fen B:W32:B1,2", "go depth 1")
+	if strings.Contains(out, "info string") || !strings.Contains(out, "score cp 10") {
+		t.Errorf("Expected a piece to be worth 1000, found:\n%s", out)
+	}
+
+	os.WriteFile(path, []byte("queen = 9\n"), 0644)
+	for _, command := range []string{"setoption name Weights value " + path, "setoption name Weights value missing.toml"} {
+		if out := textSession(command); !strings.HasPrefix(out, "info string ") {
+			t.Errorf("Expected an error for '%s', found '%s'", command, out)
+		}
+	}
+}
+
+func TestTextThreads(t *testing.T) {
+	out := textSession("setoption name Threads value 4", "ucinewgame", "position startpos", "go depth 5")
+	if strings.Contains(out, "info string") || !strings.Contains(out, "bestmove ") {
+		t.Errorf("Expected a best move from 4 threads, found:\n%s", out)
+	}
+}
+
+func TestTextErrors(t *testing.T) {
+	bad := []string{
+		"dance",
+		"position",
+		"position startpos moves 11-18",
+		"position fen X:W1:B2",
+		"go depth",
+		"go movetime soon",
+		"setoption name Hash value 16",
+		"setoption name Variant value chess",
+		"setoption name Threads value 0",
+	}
+
+	for _, command := range bad {
+		if out := textSession(command); !strings.HasPrefix(out, "info string ") {
+			t.Errorf("Expected an error for '%s', found '%s'", command, out)
+		}
+	}
+}
@@ -0,0 +1,437 @@
+package draughts
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// WinScore is the value of a won position. A win found further down
+	// the tree scores a little less, so that the search prefers the
+	// quickest win and the slowest loss.
+	WinScore = 1000000
+
+	infinity = WinScore + 1
+
+	// maxSearchDepth caps iterative deepening when no depth is given
+	maxSearchDepth = 64
+
+	// winRange is how far below WinScore a win can score: found at the
+	// deepest ply, then won as slowly as a tablebase allows
+	winRange = maxSearchDepth + tbMaxPlies
+
+	// checkInterval is how many nodes we visit between looking at the clock
+	checkInterval = 1024
+
+	// DefaultTableSize is the number of transposition table entries an
+	// Engine gets unless told otherwise
+	DefaultTableSize = 1 << 20
+)
+
+// Score is the outcome of a search: the value of the position from the
+// point of view of the player to move, the best move, and the principal
+// variation (the line of best play for both sides) starting with that move
+type Score struct {
+	Value int
+	Move  *Move
+	PV    []*Move
+	Depth int // Depth of the deepest completed iteration
+
+	// Stats counts the work done, up to that iteration. With several
+	// threads, they're the main thread's alone.
+	Stats SearchStats
+}
+
+// SearchLimits bounds a search. Depth is the maximum ply depth and Budget
+// the wall-clock time allowed; a zero value means no limit on that
+// dimension, but at least one of them should be set, or Stop closed at some
+// point. Report, if set, is called with the result of each iteration as it
+// completes.
+type SearchLimits struct {
+	Depth  int
+	Budget time.Duration
+	Stop   <-chan struct{} // Closing it stops the search, as if out of time
+	Report func(*Score)
+}
+
+// Engine holds the state kept between searches, so that what was learned
+// thinking about one move helps with the next
+type Engine struct {
+	tt *TranspositionTable
+
+	// Tablebase, if set, gives the outcome of positions with few enough
+	// pieces, so those are played perfectly rather than searched
+	Tablebase *Tablebase
+
+	// Evaluator values the positions at the end of the search, or if nil,
+	// DefaultWeights. With more than one thread, it must be safe to use
+	// from several goroutines at once, as Weights is.
+	Evaluator Evaluator
+
+	// Threads is how many goroutines search at once. One, or zero, searches
+	// on the caller's goroutine alone, and gives the same result every time.
+	Threads int
+}
+
+// NewEngine is the constructor. It allocates a transposition table of
+// `tableSize` entries; zero means no table at all.
+func NewEngine(tableSize int) *Engine {
+	e := &Engine{}
+	if tableSize > 0 {
+		e.tt = NewTranspositionTable(tableSize)
+	}
+
+	return e
+}
+
+type searcher struct {
+	tt        *TranspositionTable
+	tablebase *Tablebase
+	evaluator Evaluator
+	deadline  time.Time
+	abortable bool // Only the first iteration must run to completion
+	stop      <-chan struct{}
+	halt      *atomic.Bool // Set when a parallel search's main thread is done
+	stopped   bool
+	stats     SearchStats
+	rootBest  *Move // Best move of the previous iteration, searched first
+	order     moveOrder
+}
+
+// Search is a one-off search with a fresh Engine, see Engine.Search
+func Search(b *Board, player int, limits SearchLimits) *Score {
+	return NewEngine(DefaultTableSize).Search(b, player, limits)
+}
+
+// Search finds the best move for `player` using an alpha-beta pruned
+// negamax, deepening one ply at a time until either the depth limit is
+// reached or the time budget runs out. The result of the last completed
+// iteration is returned; the first iteration always runs to completion so
+// there is always a move to play, if one exists.
+func (e *Engine) Search(b *Board, player int, limits SearchLimits) *Score {
+	if score := e.tablebaseScore(b, player); score != nil {
+		if limits.Report != nil {
+			limits.Report(score)
+		}
+		return score
+	}
+
+	s := e.searcher(limits)
+	maxDepth := limits.Depth
+	if maxDepth <= 0 || maxDepth > maxSearchDepth {
+		maxDepth = maxSearchDepth
+	}
+
+	// The search makes and unmakes moves on its own copy of the board
+	board := *b
+
+	// Lazy SMP: helpers search the same position at the same time, sharing
+	// what they find through the transposition table for the main search to
+	// pick up, so without a table there are none. Half of them start a ply
+	// deeper, to keep a step ahead of it. Their own results are thrown away.
+	halt := &atomic.Bool{}
+	var helpers sync.WaitGroup
+	for i := 1; i < e.Threads && e.tt != nil; i++ {
+		helper := e.searcher(limits)
+		helper.halt, helper.abortable = halt, true
+		helpers.Add(1)
+		go func(board Board, first int) {
+			defer helpers.Done()
+			helper.deepen(&board, player, first, maxSearchDepth, nil)
+		}(board, 1+i%2)
+	}
+
+	best := s.deepen(&board, player, 1, maxDepth, limits.Report)
+
+	halt.Store(true)
+	helpers.Wait()
+	return best
+}
+
+// searcher sets up a search for the engine, within `limits`
+func (e *Engine) searcher(limits SearchLimits) *searcher {
+	s := &searcher{tt: e.tt, tablebase: e.Tablebase, evaluator: e.Evaluator, stop: limits.Stop}
+	if s.evaluator == nil {
+		s.evaluator = DefaultWeights
+	}
+	if limits.Budget > 0 {
+		s.deadline = time.Now().Add(limits.Budget)
+	}
+
+	return s
+}
+
+// deepen searches `b` to depth `first`, then a ply deeper each time, up to
+// depth `last` or until stopped, returning the result of the deepest
+// completed iteration, and passing each to `report` if it isn't nil
+func (s *searcher) deepen(b *Board, player, first, last int, report func(*Score)) *Score {
+	best := &Score{Value: -WinScore}
+	previous := 0 // Nodes visited by the previous iteration
+	for depth := first; depth <= last; depth++ {
+		start := s.stats.Nodes
+		value, pv := s.negamax(b, player, depth, 0, -infinity, infinity)
+		if s.stopped {
+			break
+		}
+
+		nodes := s.stats.Nodes - start
+		if previous > 0 {
+			s.stats.BranchingFactor = float64(nodes) / float64(previous)
+		}
+		previous = nodes
+
+		best = &Score{Value: value, PV: pv, Depth: depth, Stats: s.stats}
+		if len(pv) > 0 {
+			best.Move = pv[0]
+			s.rootBest = pv[0]
+		}
+		s.abortable = true
+
+		if report != nil {
+			report(best)
+		}
+
+		// No point looking deeper once the outcome is decided
+		if abs(value) >= WinScore-winRange {
+			break
+		}
+	}
+
+	return best
+}
+
+// tablebaseScore plays the position from the tablebase, if it's there: the
+// best move and the line of best play that follows, to the end of the game
+// or, if drawn, for as long as a search would look
+func (e *Engine) tablebaseScore(b *Board, player int) *Score {
+	move, outcome, plies, ok := e.Tablebase.BestMove(b, player)
+	if !ok || move == nil {
+		return nil
+	}
+
+	score := &Score{Value: tablebaseValue(outcome, plies, 0), Move: move, Depth: 1}
+	for len(score.PV) < maxSearchDepth && move != nil {
+		score.PV = append(score.PV, move)
+		b, player = b.Apply(move), Opposition(player)
+		move, _, _, _ = e.Tablebase.BestMove(b, player)
+	}
+
+	return score
+}
+
+// tablebaseValue scores a tablebase outcome `plies` from the end, found
+// `ply` plies down the tree, as the search scores wins and losses
+func tablebaseValue(outcome Outcome, plies, ply int) int {
+	switch outcome {
+	case OutcomeWin:
+		return WinScore - ply - plies
+	case OutcomeLoss:
+		return -WinScore + ply + plies
+	}
+	return 0
+}
+
+// timeUp checks the clock every so often, and flags the search as stopped
+// once we're past the deadline or have been told to stop
+func (s *searcher) timeUp() bool {
+	if s.stopped {
+		return true
+	}
+
+	if s.halt != nil && s.halt.Load() {
+		s.stopped = true
+		return true
+	}
+
+	if !s.abortable || s.stats.Nodes%checkInterval != 0 {
+		return false
+	}
+
+	if !s.deadline.IsZero() && time.Now().After(s.deadline) {
+		s.stopped = true
+	}
+
+	select {
+	case <-s.stop:
+		s.stopped = true
+	default:
+	}
+
+	return s.stopped
+}
+
+// negamax returns the value of the position for `player` along with the
+// principal variation. Values are always from the point of view of the
+// side to move, so the opponent's best is our worst: hence the negation.
+// Moves are made on `b` and unmade again, so it's left as it was found.
+func (s *searcher) negamax(b *Board, player, depth, ply, alpha, beta int) (int, []*Move) {
+	s.stats.Nodes++
+	if s.timeUp() {
+		return 0, nil
+	}
+
+	moves := b.AllMoves(player)
+	if len(moves) == 0 {
+		return -WinScore + ply, nil // No moves; we've lost
+	}
+
+	if ply > 0 {
+		if outcome, plies, ok := s.tablebase.Probe(b, player); ok {
+			return tablebaseValue(outcome, plies, ply), nil
+		}
+	}
+
+	if depth == 0 {
+		return s.captures(b, player, moves, ply, alpha, beta), nil
+	}
+
+	// If we've been here before, the table may have a usable value. Failing
+	// that, its best move is a good first guess at this one.
+	key := b.Hash(player)
+	var hashMove *Move
+	if entry, ok := s.probe(key); ok {
+		hashMove = entry.Move
+		value := valueFromTT(entry.Value, ply)
+		if ply > 0 && entry.Depth >= depth {
+			switch {
+			case entry.Bound == BoundExact:
+				return value, s.hashLine(b, player, depth)
+			case entry.Bound == BoundLower && value >= beta:
+				return value, []*Move{entry.Move}
+			case entry.Bound == BoundUpper && value <= alpha:
+				return value, nil
+			}
+		}
+	}
+
+	if ply == 0 && s.rootBest != nil {
+		hashMove = s.rootBest
+	}
+	s.order.sort(b, moves, hashMove, ply)
+
+	alphaOrig := alpha
+	best := -infinity
+	var pv []*Move
+	for i, move := range moves {
+		undo := b.MakeMove(move)
+		value, line := s.negamax(b, Opposition(player), depth-1, ply+1, -beta, -alpha)
+		b.UnmakeMove(undo)
+		if s.stopped {
+			return 0, nil
+		}
+
+		value = -value
+		if value > best {
+			best = value
+			pv = append([]*Move{move}, line...)
+		}
+
+		if value > alpha {
+			alpha = value
+		}
+
+		if alpha >= beta {
+			// Cut-off: the opponent won't allow this line
+			s.stats.Cutoffs++
+			if i == 0 {
+				s.stats.FirstCutoffs++
+			}
+			s.order.cutoff(b, move, depth, ply)
+			break
+		}
+	}
+
+	bound := BoundExact
+	if best <= alphaOrig {
+		bound = BoundUpper
+	} else if best >= beta {
+		bound = BoundLower
+	}
+	s.store(key, depth, valueToTT(best, ply), bound, pv[0])
+
+	return best, pv
+}
+
+// quiesce is the search past the horizon, which only carries on while
+// there are captures to make: the value of a position halfway through an
+// exchange is meaningless, as the evaluation can't see the pieces about to
+// be taken back
+func (s *searcher) quiesce(b *Board, player, ply, alpha, beta int) int {
+	s.stats.Nodes++
+	if s.timeUp() {
+		return 0
+	}
+
+	moves := b.AllMoves(player)
+	if len(moves) == 0 {
+		return -WinScore + ply
+	}
+
+	if outcome, plies, ok := s.tablebase.Probe(b, player); ok {
+		return tablebaseValue(outcome, plies, ply)
+	}
+
+	return s.captures(b, player, moves, ply, alpha, beta)
+}
+
+// captures searches `moves` if they're captures, or if they aren't, the
+// position is quiet and the evaluator says what it's worth. Capturing is
+// compulsory, so there's no choice of standing pat instead, as there is in
+// chess.
+func (s *searcher) captures(b *Board, player int, moves []*Move, ply, alpha, beta int) int {
+	if !b.IsCapture(moves[0]) {
+		return s.evaluator.Evaluate(b, player)
+	}
+	s.order.sort(b, moves, nil, ply)
+
+	best := -infinity
+	for _, move := range moves {
+		undo := b.MakeMove(move)
+		value := -s.quiesce(b, Opposition(player), ply+1, -beta, -alpha)
+		b.UnmakeMove(undo)
+		if s.stopped {
+			return 0
+		}
+
+		best = max(best, value)
+		alpha = max(alpha, value)
+		if alpha >= beta {
+			break
+		}
+	}
+
+	return best
+}
+
+func (s *searcher) probe(key uint64) (TTEntry, bool) {
+	if s.tt == nil {
+		return TTEntry{}, false
+	}
+	return s.tt.Probe(key)
+}
+
+func (s *searcher) store(key uint64, depth, value int, bound Bound, move *Move) {
+	if s.tt != nil {
+		s.tt.Store(key, depth, value, bound, move)
+	}
+}
+
+// hashLine rebuilds a principal variation of up to `depth` moves by
+// following best moves through the transposition table, for when an exact
+// hit cuts the search short
+func (s *searcher) hashLine(b *Board, player, depth int) []*Move {
+	line := []*Move{}
+	for len(line) < depth {
+		entry, ok := s.probe(b.Hash(player))
+		if !ok || entry.Move == nil || !ContainsMove(b.AllMoves(player), entry.Move) {
+			break
+		}
+
+		line = append(line, entry.Move)
+		b = b.Apply(entry.Move)
+		player = Opposition(player)
+	}
+
+	return line
+}
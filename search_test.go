@@ -0,0 +1,274 @@
+package draughts
+
+import (
+	"testing"
+	"time"
+)
+
+// fullWidth is a plain negamax without pruning, to check that alpha-beta
+// doesn't change the answer. Past the horizon it carries on while there are
+// captures to make, as the search does.
+func fullWidth(b *Board, player, depth, ply int) int {
+	moves := b.AllMoves(player)
+	if len(moves) == 0 {
+		return -WinScore + ply
+	}
+
+	if depth <= 0 && !b.IsCapture(moves[0]) {
+		return HeuristicValue(b, player)
+	}
+
+	best := -infinity
+	for _, move := range moves {
+		value := -fullWidth(b.Apply(move), Opposition(player), depth-1, ply+1)
+		if value > best {
+			best = value
+		}
+	}
+
+	return best
+}
+
+func TestSearchMatchesFullWidth(t *testing.T) {
+	boards := []*Board{
+		NewBoard(),
+		NewBoardFromArray([8][8]int{
+			{0, 1, 0, 1, 0, 1, 0, 1},
+			{1, 0, 1, 0, 1, 0, 1, 0},
+			{0, 1, 0, 0, 0, 1, 0, 1},
+			{0, 0, 1, 0, 0, 0, 0, 0},
+			{0, 2, 0, 2, 0, 0, 0, 0},
+			{0, 0, 0, 0, 0, 0, 2, 0},
+			{0, 2, 0, 2, 0, 2, 0, 2},
+			{2, 0, 0, 0, 2, 0, 2, 0},
+		}),
+	}
+
+	for i, board := range boards {
+		for depth := 1; depth <= 4; depth++ {
+			expected := fullWidth(board, 1, depth, 0)
+			score := NewEngine(0).Search(board, 1, SearchLimits{Depth: depth})
+			if score.Value != expected {
+				t.Errorf("Board %d, depth %d: expected value %d, found %d",
+					i, depth, expected, score.Value)
+			}
+		}
+	}
+}
+
+func TestSearchFindsWin(t *testing.T) {
+	player := 1
+
+	// Red can take green's last piece
+	board := NewBoardFromArray([8][8]int{
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 1, 0, 0, 0, 0},
+		{0, 0, 0, 0, 2, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+	})
+
+	score := Search(board, player, SearchLimits{Depth: 6})
+	if score.Value != WinScore-1 {
+		t.Errorf("Expected a win in 1, found value %d", score.Value)
+	}
+
+	expected := NewMove(player, Pos{3, 2}, Pos{5, 4})
+	if score.Move == nil || !score.Move.Equals(expected) {
+		t.Errorf("Expected best move %s", expected.AsString())
+	}
+}
+
+func TestSearchPrincipalVariation(t *testing.T) {
+	player := 1
+	board := NewBoard()
+
+	score := Search(board, player, SearchLimits{Depth: 4})
+	if score.Depth != 4 {
+		t.Errorf("Expected a completed depth of 4, found %d", score.Depth)
+	}
+
+	if len(score.PV) != 4 {
+		t.Fatalf("Expected a PV of length 4, found %d", len(score.PV))
+	}
+
+	if !score.PV[0].Equals(score.Move) {
+		t.Errorf("PV doesn't start with the best move")
+	}
+
+	// Every move along the PV must be legal in turn
+	for _, move := range score.PV {
+		if !ContainsMove(board.AllMoves(player), move) {
+			t.Fatalf("PV move %s is illegal", move.AsString())
+		}
+		board = board.Apply(move)
+		player = Opposition(player)
+	}
+}
+
+func TestSearchBudget(t *testing.T) {
+	start := time.Now()
+	score := Search(NewBoard(), 1, SearchLimits{Budget: 100 * time.Millisecond})
+	elapsed := time.Since(start)
+
+	if score.Move == nil {
+		t.Fatal("Expected a move from a time limited search")
+	}
+
+	if elapsed > time.Second {
+		t.Errorf("Search with 100ms budget took %s", elapsed)
+	}
+}
+
+func TestSearchStop(t *testing.T) {
+	stop := make(chan struct{})
+	time.AfterFunc(100*time.Millisecond, func() { close(stop) })
+
+	start := time.Now()
+	score := Search(NewBoard(), 1, SearchLimits{Stop: stop})
+	if score.Move == nil {
+		t.Fatal("Expected a move from a stopped search")
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Search took %s to stop", elapsed)
+	}
+}
+
+func TestSearchReport(t *testing.T) {
+	depths := []int{}
+	report := func(score *Score) {
+		depths = append(depths, score.Depth)
+	}
+
+	score := Search(NewBoard(), 1, SearchLimits{Depth: 4, Report: report})
+	if len(depths) != 4 || depths[3] != score.Depth {
+		t.Errorf("Expected a report for each of 4 iterations, found %v", depths)
+	}
+}
+
+func TestSearchTablebase(t *testing.T) {
+	e := NewEngine(DefaultTableSize)
+	e.Tablebase = tablebase(t)
+
+	// At the root, the tablebase's line is played out to the end
+	board, player, _ := ParseFEN(English, "W:WK1,K5:BK32")
+	_, plies, _ := e.Tablebase.Probe(board, player)
+	score := e.Search(board, player, SearchLimits{Depth: 4})
+	if score.Value != WinScore-plies || len(score.PV) != plies {
+		t.Errorf("Expected a win in %d, found %d with a line of %d", plies, score.Value, len(score.PV))
+	}
+
+	// Down the tree, the capture is forced, and what follows is looked up
+	board, player, _ = ParseFEN(English, "B:WK1,K5,14:B10,3")
+	after := board.Apply(board.AllMoves(player)[0])
+	outcome, plies, _ := e.Tablebase.Probe(after, Opposition(player))
+	score = e.Search(board, player, SearchLimits{Depth: 1})
+	if expected := -tablebaseValue(outcome, plies, 1); score.Value != expected {
+		t.Errorf("Expected %d, found %d", expected, score.Value)
+	}
+}
+
+// countingEvaluator counts the positions it's asked about
+type countingEvaluator struct {
+	calls *int
+}
+
+func (c countingEvaluator) Evaluate(b *Board, player int) int {
+	*c.calls++
+	return 0
+}
+
+func TestSearchEvaluator(t *testing.T) {
+	calls := 0
+	e := NewEngine(0)
+	e.Evaluator = countingEvaluator{&calls}
+
+	score := e.Search(NewBoard(), 1, SearchLimits{Depth: 2})
+	if calls == 0 || score.Value != 0 {
+		t.Errorf("Expected the evaluator to be used, found %d calls and a value of %d", calls, score.Value)
+	}
+}
+
+func TestSearchQuiescence(t *testing.T) {
+	// Judged where it lands, 10-7 is White's best move, but it leaves Black
+	// a capture, and the exchange that follows costs White more than 25-22
+	// does. At depth 1 the horizon falls before Black's reply; the search
+	// has to look past it.
+	board, player, err := ParseFEN(English, "W:W10,19,25,29,32:B1,4,8,11,K30")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	score := NewEngine(0).Search(board, player, SearchLimits{Depth: 1})
+	if move := board.MoveString(score.Move); move != "25-22" {
+		t.Errorf("Expected 25-22, found %s", move)
+	}
+	if len(score.PV) != 1 {
+		t.Errorf("Expected the PV to stop at the horizon, found %d moves", len(score.PV))
+	}
+}
+
+func TestSearchThreads(t *testing.T) {
+	// Several threads find the same win as one
+	board := NewBoardFromArray([8][8]int{
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 1, 0, 0, 0, 0},
+		{0, 0, 0, 0, 2, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+	})
+	engine := NewEngine(DefaultTableSize)
+	engine.Threads = 4
+	if score := engine.Search(board, 1, SearchLimits{Depth: 6}); score.Value != WinScore-1 {
+		t.Errorf("Expected a win in 1, found value %d", score.Value)
+	}
+
+	// ..and a legal line of the depth asked for, leaving the board as it was
+	board = NewBoard()
+	before := *board
+	score := engine.Search(board, 1, SearchLimits{Depth: 7})
+	if score.Depth != 7 || len(score.PV) == 0 {
+		t.Fatalf("Expected a line of depth 7, found %d moves at depth %d", len(score.PV), score.Depth)
+	}
+	if *board != before {
+		t.Errorf("Search changed the board")
+	}
+
+	player := 1
+	for _, move := range score.PV {
+		if !ContainsMove(board.AllMoves(player), move) {
+			t.Fatalf("PV move %s is illegal", move.AsString())
+		}
+		board = board.Apply(move)
+		player = Opposition(player)
+	}
+
+	// Out of time, the helpers stop along with the main search
+	start := time.Now()
+	engine.Search(NewBoard(), 1, SearchLimits{Budget: 50 * time.Millisecond})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the search to stop after 50ms, took %s", elapsed)
+	}
+}
+
+func TestSearchSingleThreadDeterministic(t *testing.T) {
+	first := NewEngine(DefaultTableSize).Search(NewBoard(), 1, SearchLimits{Depth: 6})
+	for i := 0; i < 3; i++ {
+		score := NewEngine(DefaultTableSize).Search(NewBoard(), 1, SearchLimits{Depth: 6})
+		if score.Value != first.Value || len(score.PV) != len(first.PV) {
+			t.Fatalf("Expected the same result each time, found %d then %d", first.Value, score.Value)
+		}
+		for j := range score.PV {
+			if !score.PV[j].Equals(first.PV[j]) {
+				t.Fatalf("Expected the same line each time")
+			}
+		}
+	}
+}
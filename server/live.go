@@ -0,0 +1,269 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/xpqz/draughts"
+)
+
+// Games can be followed live over a WebSocket, at /games/{id}/live. Players
+// connect with ?player=1 or ?player=2 and spectators without. Each is sent
+// the game as it stands, then an event as each move is made, however it's
+// made, and once the game ends. Players send their moves as
+//
+//	{"type": "move", "move": "11-15"}
+//
+// and are told of any mistakes with an "error" event. Timed games' clocks
+// run on the server, and a player whose time runs out loses.
+
+// watcherQueue is how many events may be waiting to go to a watcher before
+// we give up on them
+const watcherQueue = 64
+
+// EventJSON is an event sent to a game's watchers
+type EventJSON struct {
+	Type   string    `json:"type"` // "state", "move", "end" or "error"
+	Game   *GameJSON `json:"game,omitempty"`
+	Player int       `json:"player,omitempty"` // Who moved
+	Move   string    `json:"move,omitempty"`   // In PDN, as Board.MoveString writes it, for every variant
+	Error  string    `json:"error,omitempty"`
+}
+
+// ClockJSON is a game's clock as sent to clients
+type ClockJSON struct {
+	Control   string  `json:"control"`   // As draughts.ParseTimeControl reads it
+	Remaining []int64 `json:"remaining"` // Milliseconds left for players 1 and 2
+	Running   int     `json:"running"`   // Player whose time is running, or 0
+}
+
+// clockJSON describes `clock`
+func clockJSON(clock *draughts.Clock) *ClockJSON {
+	return &ClockJSON{
+		Control:   clock.Control.String(),
+		Remaining: []int64{clock.Remaining(1).Milliseconds(), clock.Remaining(2).Milliseconds()},
+		Running:   clock.Running(),
+	}
+}
+
+// watcher is a player or spectator following a game over a WebSocket
+type watcher struct {
+	conn   *wsConn
+	player int // 0 for spectators
+	events chan []byte
+}
+
+// send queues `event` to go to the watcher. One too far behind is cut off.
+func (w *watcher) send(event *EventJSON) {
+	message, _ := json.Marshal(event)
+	select {
+	case w.events <- message:
+	default:
+		w.conn.conn.Close()
+	}
+}
+
+// writeEvents sends the watcher their events until there are no more
+func (w *watcher) writeEvents() {
+	for message := range w.events {
+		if err := w.conn.WriteText(message); err != nil {
+			w.conn.conn.Close()
+		}
+	}
+	w.conn.Close()
+}
+
+// liveGame is what's kept about a game besides its moves: its clock, if
+// it's timed, and who's watching
+type liveGame struct {
+	clock    *draughts.Clock
+	timer    *time.Timer // Goes off when the running player's time runs out
+	watchers map[*watcher]bool
+}
+
+// liveGame returns the live state of the game with `id`, creating it if
+// need be. The lock must be held.
+func (s *Server) liveGame(id string) *liveGame {
+	live, ok := s.live[id]
+	if !ok {
+		live = &liveGame{watchers: map[*watcher]bool{}}
+		s.live[id] = live
+	}
+	return live
+}
+
+// startClock gives the game with `id` a clock, and starts it for the
+// player to move. The lock must be held.
+func (s *Server) startClock(id string, game *draughts.Game, tc draughts.TimeControl) {
+	live := s.liveGame(id)
+	live.clock = draughts.NewClock(tc)
+	live.clock.Start(game.Player())
+	s.setTimer(id, live)
+}
+
+// setTimer sets the game's timer for when the running player's time will
+// run out. The lock must be held.
+func (s *Server) setTimer(id string, live *liveGame) {
+	if live.timer != nil {
+		live.timer.Stop()
+	}
+
+	if player := live.clock.Running(); player != 0 {
+		live.timer = time.AfterFunc(live.clock.Remaining(player), func() { s.flagFell(id, player) })
+	}
+}
+
+// flagFell ends the game with `id` as a loss for `player`, whose time has
+// run out, unless they moved in time after all
+func (s *Server) flagFell(id string, player int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	live, game := s.live[id], s.games[id]
+	if game == nil || game.Over() || live.clock.Running() != player || !live.clock.Flagged(player) {
+		return
+	}
+
+	live.clock.Stop()
+	game.Forfeit(player)
+	s.save(id, game)
+	s.broadcast(id, &EventJSON{Type: "end", Game: s.gameJSON(id, game)})
+}
+
+// broadcast sends `event` to everyone watching the game with `id`. The lock
+// must be held.
+func (s *Server) broadcast(id string, event *EventJSON) {
+	if live, ok := s.live[id]; ok {
+		for w := range live.watchers {
+			w.send(event)
+		}
+	}
+}
+
+// play makes `move` in the game with `id`, saves the game and tells its
+// watchers. The lock must be held.
+func (s *Server) play(id string, game *draughts.Game, move *draughts.Move) error {
+	live := s.live[id]
+	timed := live != nil && live.clock != nil
+	if timed && !game.Over() && live.clock.Flagged(game.Player()) {
+		// The timer is about to go off
+		live.clock.Stop()
+		game.Forfeit(game.Player())
+		s.broadcast(id, &EventJSON{Type: "end", Game: s.gameJSON(id, game)})
+		if err := s.save(id, game); err != nil {
+			return err
+		}
+		return fail(http.StatusConflict, "Out of time")
+	}
+
+	board := game.Board()
+	if err := game.Play(move); err != nil {
+		return fail(http.StatusUnprocessableEntity, "%s", err)
+	}
+	if timed {
+		live.clock.Press(move.Player)
+		if game.Over() {
+			live.clock.Stop()
+		}
+		s.setTimer(id, live)
+	}
+
+	event := &EventJSON{Type: "move", Game: s.gameJSON(id, game), Player: move.Player,
+		Move: board.MoveString(move)}
+	s.broadcast(id, event)
+	if game.Over() {
+		s.broadcast(id, &EventJSON{Type: "end", Game: event.Game})
+	}
+
+	return s.save(id, game)
+}
+
+func (s *Server) watchGame(w http.ResponseWriter, r *http.Request, id string) {
+	player := 0
+	if value := r.URL.Query().Get("player"); value != "" {
+		var err error
+		player, err = strconv.Atoi(value)
+		if err != nil || (player != 1 && player != 2) {
+			reply(w, 0, nil, fail(http.StatusBadRequest, "Bad player '%s'", value))
+			return
+		}
+	}
+
+	s.lock.Lock()
+	_, err := s.game(id)
+	s.lock.Unlock()
+	if err != nil {
+		reply(w, 0, nil, err)
+		return
+	}
+
+	conn, err := wsUpgrade(w, r)
+	if err != nil {
+		reply(w, 0, nil, err)
+		return
+	}
+
+	watcher := &watcher{conn, player, make(chan []byte, watcherQueue)}
+	go watcher.writeEvents()
+
+	s.lock.Lock()
+	game, _ := s.game(id)
+	s.liveGame(id).watchers[watcher] = true
+	watcher.send(&EventJSON{Type: "state", Game: s.gameJSON(id, game)})
+	s.lock.Unlock()
+
+	defer func() {
+		s.lock.Lock()
+		delete(s.live[id].watchers, watcher)
+		close(watcher.events)
+		s.lock.Unlock()
+	}()
+
+	for {
+		message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		if err := s.liveMessage(id, watcher, message); err != nil {
+			s.lock.Lock()
+			watcher.send(&EventJSON{Type: "error", Error: err.Error()})
+			s.lock.Unlock()
+		}
+	}
+}
+
+// liveMessage carries out a message from a watcher
+func (s *Server) liveMessage(id string, w *watcher, message []byte) error {
+	request := struct {
+		Type string `json:"type"`
+		Move string `json:"move"`
+	}{}
+	if err := json.Unmarshal(message, &request); err != nil {
+		return fail(http.StatusBadRequest, "Bad message: %s", err)
+	}
+
+	if request.Type != "move" {
+		return fail(http.StatusBadRequest, "Unknown message type '%s'", request.Type)
+	}
+	if w.player == 0 {
+		return fail(http.StatusForbidden, "Spectators can't move")
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	game, err := s.game(id)
+	if err != nil {
+		return err
+	}
+
+	move, err := parseMove(game, request.Move, w.player)
+	if err != nil {
+		return err
+	}
+
+	return s.play(id, game, move)
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dial opens a WebSocket to `path` on `ts`
+func dial(t *testing.T, ts *httptest.Server, path string) *wsConn {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n", path)
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols ||
+		resp.Header.Get("Sec-WebSocket-Accept") != wsAccept("dGhlIHNhbXBsZSBub25jZQ==") {
+		t.Fatalf("Handshake for %s failed: %s", path, resp.Status)
+	}
+
+	return &wsConn{conn: conn, rw: bufio.NewReadWriter(reader, bufio.NewWriter(conn)), client: true}
+}
+
+// next reads the next event from `conn`
+func next(t *testing.T, conn *wsConn) *EventJSON {
+	conn.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	message, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := &EventJSON{}
+	if err := json.Unmarshal(message, event); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestLivePlay(t *testing.T) {
+	s := NewServer(NewMemoryStore())
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	var game GameJSON
+	call(t, s, "POST", "/games", "", &game)
+	path := "/games/" + game.ID + "/live"
+
+	red, green, spectator := dial(t, ts, path+"?player=1"), dial(t, ts, path+"?player=2"), dial(t, ts, path)
+	defer red.Close()
+	defer green.Close()
+	defer spectator.Close()
+	for _, conn := range []*wsConn{red, green, spectator} {
+		if event := next(t, conn); event.Type != "state" || event.Game.ID != game.ID {
+			t.Fatalf("Expected the game's state, found %+v", event)
+		}
+	}
+
+	// Moves from players, and over the REST API, reach everyone
+	red.WriteText([]byte(`{"type": "move", "move": "11-15"}`))
+	for _, conn := range []*wsConn{red, green, spectator} {
+		event := next(t, conn)
+		if event.Type != "move" || event.Player != 1 || event.Move != "11-15" {
+			t.Errorf("Expected 11-15, found %+v", event)
+		}
+	}
+
+	call(t, s, "POST", "/games/"+game.ID+"/moves", `{"move": "23-19"}`, nil)
+	for _, conn := range []*wsConn{red, green, spectator} {
+		if event := next(t, conn); event.Move != "23-19" || event.Game.Ply != 2 {
+			t.Errorf("Expected 23-19, found %+v", event)
+		}
+	}
+
+	// Mistakes are only reported to whoever made them
+	green.WriteText([]byte(`{"type": "move", "move": "22-18"}`))
+	spectator.WriteText([]byte(`{"type": "move", "move": "8-11"}`))
+	red.WriteText([]byte(`{"type": "resign"}`))
+	for _, conn := range []*wsConn{green, spectator, red} {
+		if event := next(t, conn); event.Type != "error" || event.Error == "" {
+			t.Errorf("Expected an error, found %+v", event)
+		}
+	}
+
+	red.WriteText([]byte(`{"type": "move", "move": "8-11"}`))
+	if event := next(t, green); event.Move != "8-11" {
+		t.Errorf("Expected 8-11, found %+v", event)
+	}
+
+	if status := call(t, s, "GET", path, "", nil); status != http.StatusBadRequest {
+		t.Errorf("Expected a plain GET to be refused, found %d", status)
+	}
+}
+
+func TestLiveInternational(t *testing.T) {
+	s := NewServer(NewMemoryStore())
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	var game GameJSON
+	call(t, s, "POST", "/games", `{"variant": "international"}`, &game)
+	spectator := dial(t, ts, "/games/"+game.ID+"/live")
+	defer spectator.Close()
+	next(t, spectator)
+
+	// Moves are in PDN on the bigger board too
+	call(t, s, "POST", "/games/"+game.ID+"/moves", `{"move": "32-28"}`, nil)
+	if event := next(t, spectator); event.Type != "move" || event.Move != "32-28" {
+		t.Errorf("Expected 32-28, found %+v", event)
+	}
+}
+
+func TestLiveClock(t *testing.T) {
+	s := NewServer(NewMemoryStore())
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	var game GameJSON
+	call(t, s, "POST", "/games", `{"clock": "0.005+1"}`, &game)
+	if game.Clock == nil || game.Clock.Running != 1 || game.Clock.Remaining[1] != 300 || game.Clock.Control != "0.005+1" {
+		t.Fatalf("Expected Red's clock to be running, found %+v", game.Clock)
+	}
+
+	spectator := dial(t, ts, "/games/"+game.ID+"/live")
+	defer spectator.Close()
+	next(t, spectator)
+
+	// The increment keeps Red going, but Green runs out of time
+	call(t, s, "POST", "/games/"+game.ID+"/moves", `{"move": "11-15"}`, &game)
+	if game.Clock.Running != 2 || game.Clock.Remaining[0] < 1000 {
+		t.Errorf("Expected Green's clock to be running, found %+v", game.Clock)
+	}
+	next(t, spectator)
+
+	event := next(t, spectator)
+	if event.Type != "end" || event.Game.Result != "0-1" || event.Game.Clock.Remaining[1] != 0 {
+		t.Errorf("Expected Green to lose on time, found %+v", event)
+	}
+
+	var failure struct{ Error string }
+	if status := call(t, s, "POST", "/games/"+game.ID+"/moves", `{"move": "23-19"}`, &failure); status != http.StatusUnprocessableEntity {
+		t.Errorf("Expected a move after the flag fell to be refused, found %d %s", status, failure.Error)
+	}
+}
@@ -0,0 +1,467 @@
+// Package server hosts games of draughts over HTTP, with a JSON API:
+//
+//	POST /games               start a game: {"variant": "english", "fen": "..."}, both optional,
+//	                          timed with {"clock": "5+2"}, as draughts.ParseTimeControl reads it
+//	GET  /games               list the games' IDs
+//	GET  /games/{id}          the game: the board, moves played and result
+//	GET  /games/{id}/board    just the board
+//	GET  /games/{id}/moves    the legal moves
+//	POST /games/{id}/moves    play a move: {"move": "11-15"}
+//	POST /games/{id}/engine   have the engine move: {"movetime": 1000}, optional
+//	GET  /games/{id}/live     follow the game over a WebSocket, see live.go
+//
+// Errors come back with a 4xx status and {"error": "..."}. Clocks, and who's
+// watching, are kept in memory only.
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xpqz/draughts"
+)
+
+// DefaultBudget is the engine's thinking time when not told otherwise
+const DefaultBudget = 2 * time.Second
+
+// BoardJSON is a board as sent to clients. Rows hold the board's squares,
+// as Board.Get returns them: 1 and 2 for men, -1 and -2 for kings.
+type BoardJSON struct {
+	Variant string  `json:"variant"`
+	Size    int     `json:"size"`
+	Rows    [][]int `json:"rows"`
+	FEN     string  `json:"fen"`
+}
+
+// GameJSON is a game as sent to clients
+type GameJSON struct {
+	ID         string     `json:"id"`
+	Board      BoardJSON  `json:"board"`
+	Player     int        `json:"player"` // To move
+	Ply        int        `json:"ply"`
+	Moves      []string   `json:"moves"`
+	Result     string     `json:"result"`
+	DrawReason string     `json:"drawReason,omitempty"`
+	Move       string     `json:"move,omitempty"` // The engine's move, when asked for one
+	Clock      *ClockJSON `json:"clock,omitempty"`
+}
+
+// MoveJSON is a legal move as sent to clients
+type MoveJSON struct {
+	Move    string `json:"move"`
+	Squares []int  `json:"squares"`
+	Capture bool   `json:"capture"`
+}
+
+// Server is an http.Handler serving the API
+type Server struct {
+	Budget time.Duration // Engine thinking time, unless the request says
+
+	store Store
+	lock  sync.Mutex // Guards games and live, and everything in them
+	games map[string]*draughts.Game
+	live  map[string]*liveGame
+
+	engine     *draughts.Engine
+	engineLock sync.Mutex // One search at a time, as they share the table
+
+	routes map[string]func(http.ResponseWriter, *http.Request, string)
+}
+
+// NewServer is the constructor. Games are saved to `store` as they
+// change.
+func NewServer(store Store) *Server {
+	s := &Server{
+		Budget: DefaultBudget,
+		store:  store,
+		games:  map[string]*draughts.Game{},
+		live:   map[string]*liveGame{},
+		engine: draughts.NewEngine(draughts.DefaultTableSize),
+	}
+
+	// Keyed by method and path, with {id} standing for a game ID
+	s.routes = map[string]func(http.ResponseWriter, *http.Request, string){
+		"POST /games":             s.createGame,
+		"GET /games":              s.listGames,
+		"GET /games/{id}":         s.getGame,
+		"GET /games/{id}/board":   s.getBoard,
+		"GET /games/{id}/moves":   s.legalMoves,
+		"POST /games/{id}/moves":  s.playMove,
+		"POST /games/{id}/engine": s.engineMove,
+		"GET /games/{id}/live":    s.watchGame,
+	}
+
+	return s
+}
+
+// ServeHTTP routes a request to its handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "games" || len(parts) > 3 {
+		reply(w, 0, nil, fail(http.StatusNotFound, "No such page"))
+		return
+	}
+
+	route := r.Method + " /games"
+	id := ""
+	if len(parts) > 1 {
+		id = parts[1]
+		route += "/{id}"
+	}
+	if len(parts) > 2 {
+		route += "/" + parts[2]
+	}
+
+	handler, ok := s.routes[route]
+	if !ok {
+		reply(w, 0, nil, fail(http.StatusNotFound, "No such page"))
+		return
+	}
+
+	handler(w, r, id)
+}
+
+// httpError is an error with the HTTP status to report it with
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string {
+	return e.err.Error()
+}
+
+// fail builds an httpError
+func fail(status int, format string, args ...interface{}) error {
+	return &httpError{status, fmt.Errorf(format, args...)}
+}
+
+// reply sends `value` as JSON, or the error if `err` is set
+func reply(w http.ResponseWriter, status int, value interface{}, err error) {
+	if err != nil {
+		status = http.StatusInternalServerError
+		if e, ok := err.(*httpError); ok {
+			status = e.status
+		}
+		value = map[string]string{"error": err.Error()}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// decode reads the JSON request body into `value`. An empty body leaves
+// `value` as it was.
+func decode(r *http.Request, value interface{}) error {
+	err := json.NewDecoder(r.Body).Decode(value)
+	if err != nil && err != io.EOF {
+		return fail(http.StatusBadRequest, "Bad request body: %s", err)
+	}
+
+	return nil
+}
+
+// newID makes up a random game ID
+func newID() string {
+	bytes := make([]byte, 8)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
+// game finds the game with `id`, loading it from the store if it's not in
+// memory. The lock must be held.
+func (s *Server) game(id string) (*draughts.Game, error) {
+	if game, ok := s.games[id]; ok {
+		return game, nil
+	}
+
+	game, err := s.store.Load(id)
+	if err == ErrNotFound {
+		return nil, fail(http.StatusNotFound, "No game '%s'", id)
+	}
+	if err == ErrBadID {
+		return nil, fail(http.StatusBadRequest, "Bad game ID '%s'", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.games[id] = game
+	return game, nil
+}
+
+// save keeps `game` in memory and in the store. The lock must be held.
+func (s *Server) save(id string, game *draughts.Game) error {
+	s.games[id] = game
+	return s.store.Save(id, game)
+}
+
+// boardJSON describes `board` with `player` to move
+func boardJSON(board *draughts.Board, player int) BoardJSON {
+	v := board.Variant()
+	rows := make([][]int, v.Size)
+	for y := range rows {
+		rows[y] = make([]int, v.Size)
+		for x := range rows[y] {
+			rows[y][x] = board.Get(draughts.Pos{X: x, Y: y})
+		}
+	}
+
+	return BoardJSON{v.Name, v.Size, rows, board.FEN(player)}
+}
+
+// gameJSON describes `game`. The lock must be held.
+func (s *Server) gameJSON(id string, game *draughts.Game) *GameJSON {
+	moves := []string{}
+	for ply, move := range game.Moves() {
+		board, _ := game.BoardAt(ply)
+		moves = append(moves, board.MoveString(move))
+	}
+
+	result := &GameJSON{
+		ID:         id,
+		Board:      boardJSON(game.Board(), game.Player()),
+		Player:     game.Player(),
+		Ply:        game.Ply(),
+		Moves:      moves,
+		Result:     game.Result(),
+		DrawReason: game.DrawReason(),
+	}
+	if live, ok := s.live[id]; ok && live.clock != nil {
+		result.Clock = clockJSON(live.clock)
+	}
+
+	return result
+}
+
+func (s *Server) createGame(w http.ResponseWriter, r *http.Request, id string) {
+	request := struct {
+		Variant string `json:"variant"`
+		FEN     string `json:"fen"`
+		Clock   string `json:"clock"` // Time control, for a timed game
+	}{Variant: draughts.English.Name}
+	var tc draughts.TimeControl
+
+	game, err := func() (*draughts.Game, error) {
+		if err := decode(r, &request); err != nil {
+			return nil, err
+		}
+
+		v, err := draughts.VariantByName(request.Variant)
+		if err != nil {
+			return nil, fail(http.StatusBadRequest, "%s", err)
+		}
+
+		if request.Clock != "" {
+			if tc, err = draughts.ParseTimeControl(request.Clock); err != nil {
+				return nil, fail(http.StatusBadRequest, "%s", err)
+			}
+		}
+
+		if request.FEN == "" {
+			return draughts.NewGame(v), nil
+		}
+
+		board, player, err := draughts.ParseFEN(v, request.FEN)
+		if err != nil {
+			return nil, fail(http.StatusBadRequest, "%s", err)
+		}
+		return draughts.NewGameFromPosition(board, player), nil
+	}()
+	if err != nil {
+		reply(w, 0, nil, err)
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	id = newID()
+	if request.Clock != "" {
+		s.startClock(id, game, tc)
+	}
+	err = s.save(id, game)
+	reply(w, http.StatusCreated, s.gameJSON(id, game), err)
+}
+
+func (s *Server) listGames(w http.ResponseWriter, r *http.Request, id string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Games not yet saved are still in memory
+	ids, err := s.store.List()
+	for id := range s.games {
+		if !contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	reply(w, http.StatusOK, map[string][]string{"games": ids}, err)
+}
+
+// contains is true if `ids` includes `id`
+func contains(ids []string, id string) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) getGame(w http.ResponseWriter, r *http.Request, id string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	game, err := s.game(id)
+	if err != nil {
+		reply(w, 0, nil, err)
+		return
+	}
+
+	reply(w, http.StatusOK, s.gameJSON(id, game), nil)
+}
+
+func (s *Server) getBoard(w http.ResponseWriter, r *http.Request, id string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	game, err := s.game(id)
+	if err != nil {
+		reply(w, 0, nil, err)
+		return
+	}
+
+	reply(w, http.StatusOK, boardJSON(game.Board(), game.Player()), nil)
+}
+
+func (s *Server) legalMoves(w http.ResponseWriter, r *http.Request, id string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	game, err := s.game(id)
+	if err != nil {
+		reply(w, 0, nil, err)
+		return
+	}
+
+	board := game.Board()
+	v := board.Variant()
+	moves := []MoveJSON{}
+	if !game.Over() {
+		for _, move := range game.LegalMoves() {
+			squares := []int{}
+			for _, p := range move.Squares {
+				squares = append(squares, v.SquareNumber(p))
+			}
+			moves = append(moves, MoveJSON{board.MoveString(move), squares, board.IsCapture(move)})
+		}
+	}
+
+	reply(w, http.StatusOK, map[string][]MoveJSON{"moves": moves}, nil)
+}
+
+func (s *Server) playMove(w http.ResponseWriter, r *http.Request, id string) {
+	request := struct {
+		Move string `json:"move"`
+	}{}
+	if err := decode(r, &request); err != nil {
+		reply(w, 0, nil, err)
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	game, err := s.game(id)
+	if err != nil {
+		reply(w, 0, nil, err)
+		return
+	}
+
+	move, err := parseMove(game, request.Move, game.Player())
+	if err == nil {
+		err = s.play(id, game, move)
+	}
+	if err != nil {
+		reply(w, 0, nil, err)
+		return
+	}
+
+	reply(w, http.StatusOK, s.gameJSON(id, game), nil)
+}
+
+// parseMove reads `text` as a move by `player` in `game`. Squares may be
+// separated by '-', 'x' or spaces.
+func parseMove(game *draughts.Game, text string, player int) (*draughts.Move, error) {
+	text = strings.NewReplacer("-", " ", "x", " ").Replace(text)
+	move, err := game.Board().Variant().ParseMove(text, player)
+	if err != nil {
+		return nil, fail(http.StatusUnprocessableEntity, "%s", err)
+	}
+
+	return move, nil
+}
+
+func (s *Server) engineMove(w http.ResponseWriter, r *http.Request, id string) {
+	request := struct {
+		MoveTime int `json:"movetime"` // Milliseconds
+	}{}
+	if err := decode(r, &request); err != nil {
+		reply(w, 0, nil, err)
+		return
+	}
+
+	budget := s.Budget
+	if request.MoveTime > 0 {
+		budget = time.Duration(request.MoveTime) * time.Millisecond
+	}
+
+	// Search without holding the lock, so other games carry on meanwhile
+	s.lock.Lock()
+	game, err := s.game(id)
+	if err == nil && game.Over() {
+		err = fail(http.StatusConflict, "Game is over")
+	}
+	var (
+		board       *draughts.Board
+		player, ply int
+	)
+	if err == nil {
+		board, player, ply = game.Board(), game.Player(), game.Ply()
+	}
+	s.lock.Unlock()
+	if err != nil {
+		reply(w, 0, nil, err)
+		return
+	}
+
+	s.engineLock.Lock()
+	score := s.engine.Search(board, player, draughts.SearchLimits{Budget: budget})
+	s.engineLock.Unlock()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if game.Ply() != ply {
+		reply(w, 0, nil, fail(http.StatusConflict, "Game moved on while thinking"))
+		return
+	}
+
+	if err := s.play(id, game, score.Move); err != nil {
+		reply(w, 0, nil, err)
+		return
+	}
+
+	result := s.gameJSON(id, game)
+	result.Move = board.MoveString(score.Move)
+	reply(w, http.StatusOK, result, nil)
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// call makes a request of `s`, decoding the JSON reply into `result`, and
+// returns the status
+func call(t *testing.T, s *Server, method, path, body string, result interface{}) int {
+	r := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+
+	if result != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), result); err != nil {
+			t.Fatalf("%s %s: bad JSON reply %s", method, path, w.Body.String())
+		}
+	}
+
+	return w.Code
+}
+
+func TestServerGame(t *testing.T) {
+	s := NewServer(NewMemoryStore())
+
+	var game GameJSON
+	if status := call(t, s, "POST", "/games", "", &game); status != http.StatusCreated {
+		t.Fatalf("Expected the game to be created, found %d", status)
+	}
+	if game.Board.Variant != "english" || game.Player != 1 || game.Result != "*" || len(game.Board.Rows) != 8 {
+		t.Errorf("Unexpected new game %+v", game)
+	}
+
+	var moves struct{ Moves []MoveJSON }
+	call(t, s, "GET", "/games/"+game.ID+"/moves", "", &moves)
+	if len(moves.Moves) != 7 {
+		t.Errorf("Expected 7 opening moves, found %d", len(moves.Moves))
+	}
+
+	if status := call(t, s, "POST", "/games/"+game.ID+"/moves", `{"move": "11-15"}`, &game); status != http.StatusOK {
+		t.Fatalf("Expected 11-15 to be played, found %d", status)
+	}
+	if game.Ply != 1 || game.Moves[0] != "11-15" || game.Board.Rows[3][4] != 1 {
+		t.Errorf("Move not played: %+v", game)
+	}
+
+	var failure struct{ Error string }
+	if status := call(t, s, "POST", "/games/"+game.ID+"/moves", `{"move": "22-17-13"}`, &failure); status != http.StatusUnprocessableEntity || failure.Error == "" {
+		t.Errorf("Expected an illegal move to be refused, found %d", status)
+	}
+
+	if status := call(t, s, "POST", "/games/"+game.ID+"/engine", `{"movetime": 50}`, &game); status != http.StatusOK {
+		t.Fatalf("Expected an engine move, found %d", status)
+	}
+	if game.Ply != 2 || game.Move == "" || game.Player != 1 {
+		t.Errorf("Engine didn't move: %+v", game)
+	}
+
+	var board BoardJSON
+	call(t, s, "GET", "/games/"+game.ID+"/board", "", &board)
+	if board.FEN != game.Board.FEN {
+		t.Errorf("Board and game disagree: %s, %s", board.FEN, game.Board.FEN)
+	}
+
+	var list struct{ Games []string }
+	call(t, s, "GET", "/games", "", &list)
+	if len(list.Games) != 1 || list.Games[0] != game.ID {
+		t.Errorf("Expected just our game, found %v", list.Games)
+	}
+}
+
+func TestServerFromPosition(t *testing.T) {
+	s := NewServer(NewMemoryStore())
+
+	var game GameJSON
+	call(t, s, "POST", "/games", `{"variant": "russian", "fen": "W:W22:B18"}`, &game)
+	if game.Board.Variant != "russian" || game.Player != 2 {
+		t.Fatalf("Unexpected game %+v", game)
+	}
+
+	call(t, s, "POST", "/games/"+game.ID+"/moves", `{"move": "22x15"}`, &game)
+	if game.Result != "1-0" {
+		t.Errorf("Expected White to have won, found %s", game.Result)
+	}
+
+	if status := call(t, s, "POST", "/games/"+game.ID+"/engine", "", nil); status != http.StatusConflict {
+		t.Errorf("Expected no engine move once the game is over, found %d", status)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	s := NewServer(NewMemoryStore())
+
+	requests := []struct {
+		method, path, body string
+		status             int
+	}{
+		{"GET", "/games/nope", "", http.StatusNotFound},
+		{"POST", "/games/nope/moves", `{"move": "11-15"}`, http.StatusNotFound},
+		{"POST", "/games", `{"variant": "chess"}`, http.StatusBadRequest},
+		{"POST", "/games", `{"fen": "X:W1:B2"}`, http.StatusBadRequest},
+		{"POST", "/games", `{"variant": `, http.StatusBadRequest},
+	}
+
+	for _, req := range requests {
+		var failure struct{ Error string }
+		if status := call(t, s, req.method, req.path, req.body, &failure); status != req.status || failure.Error == "" {
+			t.Errorf("%s %s: expected %d with an error, found %d", req.method, req.path, req.status, status)
+		}
+	}
+}
+
+func TestServerReloadsGames(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var game GameJSON
+	s := NewServer(store)
+	call(t, s, "POST", "/games", "", &game)
+	call(t, s, "POST", "/games/"+game.ID+"/moves", `{"move": "9-14"}`, &game)
+
+	// A new server, after a restart say, finds the game in the store
+	var again GameJSON
+	if status := call(t, NewServer(store), "GET", "/games/"+game.ID, "", &again); status != http.StatusOK {
+		t.Fatalf("Expected the game to be found, found %d", status)
+	}
+	if again.Board.FEN != game.Board.FEN || again.Ply != 1 {
+		t.Errorf("Reloaded game differs: %+v", again)
+	}
+
+	// IDs the store can't keep a game under are the client's mistake
+	var failure struct{ Error string }
+	for _, path := range []string{"/games/a.b", "/games/..%5Cescape/board"} {
+		if status := call(t, s, "GET", path, "", &failure); status != http.StatusBadRequest {
+			t.Errorf("%s: expected %d, found %d", path, http.StatusBadRequest, status)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/xpqz/draughts"
+)
+
+// Store keeps games between requests. The server holds the games it's
+// serving in memory, and saves each to its Store as it changes, so a Store
+// that writes somewhere lasting lets games outlive the server.
+type Store interface {
+	// Save stores `game` under `id`, replacing any game already there
+	Save(id string, game *draughts.Game) error
+
+	// Load returns the game stored under `id`, or ErrNotFound, or ErrBadID
+	// if `id` can't name a game in this store
+	Load(id string) (*draughts.Game, error)
+
+	// List returns the IDs of all the stored games, in order
+	List() ([]string, error)
+}
+
+// ErrNotFound is returned by Store.Load when there's no such game
+var ErrNotFound = fmt.Errorf("No such game")
+
+// ErrBadID is returned by a Store when it's given an ID it can't keep a
+// game under
+var ErrBadID = fmt.Errorf("Bad game ID")
+
+// MemoryStore keeps games in memory only, so they're lost when the server
+// stops
+type MemoryStore struct {
+	lock  sync.Mutex
+	games map[string]*draughts.Game
+}
+
+// NewMemoryStore is the constructor
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{games: map[string]*draughts.Game{}}
+}
+
+// Save stores `game` under `id`
+func (s *MemoryStore) Save(id string, game *draughts.Game) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.games[id] = game
+	return nil
+}
+
+// Load returns the game stored under `id`
+func (s *MemoryStore) Load(id string) (*draughts.Game, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	game, ok := s.games[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return game, nil
+}
+
+// List returns the IDs of all the stored games
+func (s *MemoryStore) List() ([]string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	ids := []string{}
+	for id := range s.games {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// FileStore keeps each game as a PDN file in a directory. Moves undone but
+// not yet replaced are not kept.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore is the constructor. The directory is created if need be.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &FileStore{dir}, nil
+}
+
+// path is where the game with `id` is kept, or ErrBadID if `id` would put
+// it anywhere but the directory
+func (s *FileStore) path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return "", ErrBadID
+	}
+
+	return filepath.Join(s.dir, id+".pdn"), nil
+}
+
+// Save writes `game` to its file. It's written to a temporary file first,
+// so a crash part way through doesn't lose the previous version.
+func (s *FileStore) Save(id string, game *draughts.Game) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(game.PDN().String()), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// Load reads the game stored under `id`
+func (s *FileStore) Load(id string) (*draughts.Game, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	games, err := draughts.ParsePDN(f)
+	if err != nil {
+		return nil, err
+	}
+	if len(games) != 1 {
+		return nil, fmt.Errorf("Expected one game in %s, found %d", path, len(games))
+	}
+
+	return draughts.NewGameFromPDN(games[0])
+}
+
+// List returns the IDs of all the games in the directory
+func (s *FileStore) List() ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.pdn"))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, path := range paths {
+		ids = append(ids, strings.TrimSuffix(filepath.Base(path), ".pdn"))
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xpqz/draughts"
+)
+
+// testStore checks a Store keeps games, however it does it
+func testStore(t *testing.T, store Store) {
+	if _, err := store.Load("missing"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, found %v", err)
+	}
+
+	board, player, _ := draughts.ParseFEN(draughts.Russian, "W:W22,K30:B1,5")
+	games := map[string]*draughts.Game{
+		"b": draughts.NewGame(draughts.English),
+		"a": draughts.NewGameFromPosition(board, player),
+	}
+
+	// One with some moves, and an agreed draw
+	for _, text := range []string{"11-15", "23-19"} {
+		move, _ := games["b"].Board().ResolveMove(text, games["b"].Player())
+		games["b"].Play(move)
+	}
+	games["b"].AgreeDraw()
+
+	for id, game := range games {
+		if err := store.Save(id, game); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids, err := store.List()
+	if err != nil || !reflect.DeepEqual(ids, []string{"a", "b"}) {
+		t.Errorf("Expected games a and b, found %v (%v)", ids, err)
+	}
+
+	for id, game := range games {
+		loaded, err := store.Load(id)
+		if err != nil {
+			t.Fatalf("%s: %s", id, err)
+		}
+
+		if loaded.Board().FEN(loaded.Player()) != game.Board().FEN(game.Player()) ||
+			loaded.Ply() != game.Ply() || loaded.Result() != game.Result() {
+			t.Errorf("%s: game didn't survive the store", id)
+		}
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, store)
+
+	if err := store.Save("../escape", draughts.NewGame(draughts.English)); err != ErrBadID {
+		t.Errorf("Expected ErrBadID for an ID with a path in it, found %v", err)
+	}
+	if _, err := store.Load("a.b"); err != ErrBadID {
+		t.Errorf("Expected ErrBadID for an ID with a dot in it, found %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"testing"
+)
+
+// wsPipe connects a WebSocket client and server in memory
+func wsPipe() (client, server *wsConn) {
+	a, b := net.Pipe()
+	client = &wsConn{conn: a, rw: bufio.NewReadWriter(bufio.NewReader(a), bufio.NewWriter(a)), client: true}
+	server = &wsConn{conn: b, rw: bufio.NewReadWriter(bufio.NewReader(b), bufio.NewWriter(b))}
+	return client, server
+}
+
+func TestWebSocketAccept(t *testing.T) {
+	// The example from RFC 6455
+	if accept := wsAccept("dGhlIHNhbXBsZSBub25jZQ=="); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Unexpected accept key %s", accept)
+	}
+}
+
+func TestWebSocketMessages(t *testing.T) {
+	client, server := wsPipe()
+	defer client.conn.Close()
+
+	long := bytes.Repeat([]byte("x"), 70000)
+	go func() {
+		client.WriteText([]byte("hello"))
+		client.writeFrame(wsPing, []byte("ping"))
+		client.WriteText(long[:300])
+
+		// A message in pieces
+		client.rw.Write([]byte{wsText, 0x83, 0, 0, 0, 0, 'o', 'n', 'e'})
+		client.rw.Write([]byte{0x80 | wsContinuation, 0x83, 1, 1, 1, 1, 't' ^ 1, 'w' ^ 1, 'o' ^ 1})
+		client.rw.Flush()
+
+		client.WriteText(long)
+	}()
+
+	// The server answers the ping while reading
+	pong := make(chan string, 1)
+	go func() {
+		_, opcode, payload, _ := client.readFrame()
+		if opcode != wsPong {
+			payload = nil
+		}
+		pong <- string(payload)
+	}()
+
+	expected := []string{"hello", string(long[:300]), "onetwo"}
+	for i, want := range expected {
+		message, err := server.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(message) != want {
+			t.Errorf("Message %d: expected %.20q, found %.20q", i, want, message)
+		}
+	}
+
+	if answer := <-pong; answer != "ping" {
+		t.Errorf("Expected the ping to be answered, found %q", answer)
+	}
+
+	if _, err := server.ReadMessage(); err == nil {
+		t.Errorf("Expected a message too big to be refused")
+	}
+}
+
+func TestWebSocketClose(t *testing.T) {
+	client, server := wsPipe()
+	answer := make(chan byte, 1)
+	go func() {
+		client.writeFrame(wsClose, nil)
+		_, opcode, _, _ := client.readFrame()
+		answer <- opcode
+	}()
+
+	if _, err := server.ReadMessage(); err != io.EOF {
+		t.Errorf("Expected EOF, found %v", err)
+	}
+	if opcode := <-answer; opcode != wsClose {
+		t.Errorf("Expected the close to be answered, found %d", opcode)
+	}
+
+	// Servers mustn't mask, clients must
+	client, server = wsPipe()
+	defer client.conn.Close()
+	client.client = false
+	go client.WriteText([]byte("hi"))
+	if _, err := server.ReadMessage(); err == nil {
+		t.Errorf("Expected an unmasked client frame to be refused")
+	}
+}
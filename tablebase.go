@@ -0,0 +1,494 @@
+package draughts
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+// An endgame tablebase knows the outcome with perfect play of every
+// English draughts position with up to so many pieces, and how many plies
+// it takes to get there: the quickest win, or the slowest loss.
+//
+// Positions are grouped by material, the number of men and kings each side
+// has, and always stored with Red to move; a position with Green to move
+// is turned round first, swapping the colours. Within a material the men
+// and then the kings of each side are numbered by their combination of
+// squares, giving every position an index. The values are a byte each,
+// run-length encoded in blocks of tbBlockSize positions, with the start of
+// every block recorded so that any one can be found without unpacking the
+// rest. A block that doesn't shrink that way is kept as it is.
+
+// tbBlockSize is the number of positions in each independently encoded
+// block
+const tbBlockSize = 4096
+
+// tbMagic starts every tablebase file
+const tbMagic = "DRTB\x01"
+
+// Outcome is how a position ends with perfect play, for the player to move
+type Outcome int
+
+// Outcomes
+const (
+	OutcomeLoss Outcome = -1
+	OutcomeDraw Outcome = 0
+	OutcomeWin  Outcome = 1
+)
+
+// String names the outcome
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeWin:
+		return "win"
+	case OutcomeLoss:
+		return "loss"
+	}
+	return "draw"
+}
+
+// A value is stored as the number of plies to the end of the game, plus
+// one, leaving 0 for a draw. The player to move loses in an even number of
+// plies, and wins in an odd number.
+const tbMaxPlies = 254
+
+// tbValue encodes a win or loss in `plies`
+func tbValue(plies int) byte {
+	return byte(plies + 1)
+}
+
+// tbOutcome decodes a stored value
+func tbOutcome(value byte) (Outcome, int) {
+	switch {
+	case value == 0:
+		return OutcomeDraw, 0
+	case value%2 == 1:
+		return OutcomeLoss, int(value) - 1
+	}
+	return OutcomeWin, int(value) - 1
+}
+
+// binomial[n][k] is n choose k
+var binomial [33][33]uint64
+
+func init() {
+	for n := range binomial {
+		binomial[n][0] = 1
+		for k := 1; k <= n; k++ {
+			binomial[n][k] = binomial[n-1][k-1] + binomial[n-1][k]
+		}
+	}
+}
+
+// colexRank numbers a set of squares among all those of the same size, in
+// colexicographic order
+func colexRank(set uint32) uint64 {
+	rank := uint64(0)
+	for i := 1; set != 0; i++ {
+		rank += binomial[bits.TrailingZeros32(set)][i]
+		set &= set - 1
+	}
+	return rank
+}
+
+// colexSet is the inverse of colexRank: the `rank`th set of `k` squares
+// numbered below `n`
+func colexSet(rank uint64, k, n int) uint32 {
+	set := uint32(0)
+	for ; k > 0; k-- {
+		n--
+		for binomial[n][k] > rank {
+			n--
+		}
+		set |= 1 << uint(n)
+		rank -= binomial[n][k]
+	}
+	return set
+}
+
+// compress renumbers the squares of `set`, all of which are in `within`,
+// by their position among the squares of `within`
+func compress(set, within uint32) uint32 {
+	compressed := uint32(0)
+	for ; set != 0; set &= set - 1 {
+		bit := set & -set
+		compressed |= 1 << uint(bits.OnesCount32(within&(bit-1)))
+	}
+	return compressed
+}
+
+// expand is the inverse of compress
+func expand(compressed, within uint32) uint32 {
+	set := uint32(0)
+	for ; compressed != 0; compressed >>= 1 {
+		bit := within & -within
+		if compressed&1 != 0 {
+			set |= bit
+		}
+		within &^= bit
+	}
+	return set
+}
+
+// flip turns the board round, and swaps the colours, so that the position
+// is the same but for who is Red
+func (bb Bitboard) flip() Bitboard {
+	return Bitboard{
+		Red:   bits.Reverse32(bb.Green),
+		Green: bits.Reverse32(bb.Red),
+		Kings: bits.Reverse32(bb.Kings),
+	}
+}
+
+// material is the number of men and kings of the player to move, [0], and
+// of their opponent, [1]
+type material struct {
+	men, kings [2]int
+}
+
+// materialOf counts the pieces of a position with Red to move
+func materialOf(bb Bitboard) material {
+	return material{
+		men:   [2]int{bits.OnesCount32(bb.Red &^ bb.Kings), bits.OnesCount32(bb.Green &^ bb.Kings)},
+		kings: [2]int{bits.OnesCount32(bb.Red & bb.Kings), bits.OnesCount32(bb.Green & bb.Kings)},
+	}
+}
+
+// flip is the material with the other player to move
+func (m material) flip() material {
+	return material{
+		men:   [2]int{m.men[1], m.men[0]},
+		kings: [2]int{m.kings[1], m.kings[0]},
+	}
+}
+
+// pieces is the number of pieces on the board
+func (m material) pieces() int {
+	return m.men[0] + m.men[1] + m.kings[0] + m.kings[1]
+}
+
+// String describes the material, the player to move's first
+func (m material) String() string {
+	return fmt.Sprintf("%dm%dk v %dm%dk", m.men[0], m.kings[0], m.men[1], m.kings[1])
+}
+
+// Men can't stand on the row where they'd be crowned, so each side's men
+// have 28 squares to choose from: Red's all but the bottom row, Green's
+// all but the top.
+const (
+	menSquares  = 28
+	greenOffset = 4
+)
+
+// size is the number of positions indexed. Some are impossible, having men
+// of both sides on the same square.
+func (m material) size() uint64 {
+	free := 32 - m.men[0] - m.men[1]
+	return binomial[menSquares][m.men[0]] * binomial[menSquares][m.men[1]] *
+		binomial[free][m.kings[0]] * binomial[free-m.kings[0]][m.kings[1]]
+}
+
+// index numbers a position with this material and Red to move
+func (m material) index(bb Bitboard) uint64 {
+	redMen, greenMen := bb.Red&^bb.Kings, bb.Green&^bb.Kings
+	redKings, greenKings := bb.Red&bb.Kings, bb.Green&bb.Kings
+	free := ^(redMen | greenMen)
+
+	index := colexRank(redMen)
+	index = index*binomial[menSquares][m.men[1]] + colexRank(greenMen>>greenOffset)
+	index = index*binomial[32-m.men[0]-m.men[1]][m.kings[0]] + colexRank(compress(redKings, free))
+	free &^= redKings
+	index = index*binomial[32-m.men[0]-m.men[1]-m.kings[0]][m.kings[1]] + colexRank(compress(greenKings, free))
+
+	return index
+}
+
+// position is the inverse of index. It returns false for an impossible
+// position.
+func (m material) position(index uint64) (Bitboard, bool) {
+	free := 32 - m.men[0] - m.men[1]
+	greenKingSets := binomial[free-m.kings[0]][m.kings[1]]
+	redKingSets := binomial[free][m.kings[0]]
+	greenMenSets := binomial[menSquares][m.men[1]]
+
+	greenKings := index % greenKingSets
+	index /= greenKingSets
+	redKings := index % redKingSets
+	index /= redKingSets
+	greenMen := colexSet(index%greenMenSets, m.men[1], menSquares) << greenOffset
+	redMen := colexSet(index/greenMenSets, m.men[0], menSquares)
+	if redMen&greenMen != 0 {
+		return Bitboard{}, false
+	}
+
+	squares := ^(redMen | greenMen)
+	red := expand(colexSet(redKings, m.kings[0], free), squares)
+	squares &^= red
+	green := expand(colexSet(greenKings, m.kings[1], free-m.kings[0]), squares)
+
+	return Bitboard{Red: redMen | red, Green: greenMen | green, Kings: red | green}, true
+}
+
+// tbSlice holds the values of every position with one material
+type tbSlice struct {
+	material material
+	size     uint64
+	offsets  []uint32 // Where each block starts in data
+	data     []byte   // Runs of values: the value, then the run length as a uvarint
+	values   []byte   // The values as they are, kept while generating
+}
+
+// newSlice encodes `values`
+func newSlice(m material, values []byte) *tbSlice {
+	s := &tbSlice{material: m, size: uint64(len(values))}
+	var encoded []byte
+	for start := 0; start < len(values); start += tbBlockSize {
+		s.offsets = append(s.offsets, uint32(len(s.data)))
+		block := values[start:min(start+tbBlockSize, len(values))]
+
+		encoded = encoded[:0]
+		for i := 0; i < len(block) && len(encoded) < len(block); {
+			run := 1
+			for i+run < len(block) && block[i+run] == block[i] {
+				run++
+			}
+			encoded = append(encoded, block[i])
+			encoded = binary.AppendUvarint(encoded, uint64(run))
+			i += run
+		}
+
+		if len(encoded) < len(block) {
+			s.data = append(s.data, encoded...)
+		} else {
+			s.data = append(s.data, block...)
+		}
+	}
+
+	return s
+}
+
+// get returns the value of the position at `index`
+func (s *tbSlice) get(index uint64) byte {
+	if s.values != nil {
+		return s.values[index]
+	}
+
+	n := index / tbBlockSize
+	start, end := uint64(s.offsets[n]), uint64(len(s.data))
+	if n+1 < uint64(len(s.offsets)) {
+		end = uint64(s.offsets[n+1])
+	}
+
+	// Only a block kept as it is takes up a byte a position
+	skip := index % tbBlockSize
+	if end-start == min(tbBlockSize, s.size-n*tbBlockSize) {
+		return s.data[start+skip]
+	}
+
+	data := s.data[start:end]
+	for {
+		value := data[0]
+		run, n := binary.Uvarint(data[1:])
+		if skip < run {
+			return value
+		}
+		skip -= run
+		data = data[1+n:]
+	}
+}
+
+// valid checks that every block holds exactly its number of values, so
+// that get can't run off the end of one
+func (s *tbSlice) valid() bool {
+	for n := range s.offsets {
+		start, end := uint64(s.offsets[n]), uint64(len(s.data))
+		if n+1 < len(s.offsets) {
+			end = uint64(s.offsets[n+1])
+		}
+
+		size := min(tbBlockSize, s.size-uint64(n)*tbBlockSize)
+		if end-start == size {
+			continue // Kept as it is
+		}
+
+		data := s.data[start:end]
+		for total := uint64(0); total != size; {
+			if len(data) < 2 {
+				return false
+			}
+			run, used := binary.Uvarint(data[1:])
+			if used <= 0 || run == 0 || run > size-total {
+				return false
+			}
+			total += run
+			data = data[1+used:]
+		}
+		if len(data) != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Tablebase holds the outcome of every English draughts position with up to
+// Pieces pieces. See GenerateTablebase and ReadTablebase.
+type Tablebase struct {
+	Pieces int
+	slices map[material]*tbSlice
+}
+
+// lookup returns the stored value of a position with Red to move
+func (tb *Tablebase) lookup(bb Bitboard) (byte, bool) {
+	if bb.Red == 0 {
+		return tbValue(0), true // Nothing left to move
+	}
+
+	m := materialOf(bb)
+	s, ok := tb.slices[m]
+	if !ok {
+		return 0, false
+	}
+	return s.get(m.index(bb)), true
+}
+
+// Probe returns the outcome of the position with `player` to move, and how
+// many plies it takes with perfect play. It returns false if the position
+// isn't covered: it has too many pieces, or isn't English draughts.
+func (tb *Tablebase) Probe(b *Board, player int) (Outcome, int, bool) {
+	if tb == nil || !b.Variant().bitboardRules() {
+		return OutcomeDraw, 0, false
+	}
+
+	bb := b.Bitboard()
+	if bits.OnesCount32(bb.Red|bb.Green) > tb.Pieces {
+		return OutcomeDraw, 0, false
+	}
+	if player == 2 {
+		bb = bb.flip()
+	}
+
+	value, ok := tb.lookup(bb)
+	if !ok {
+		return OutcomeDraw, 0, false
+	}
+	outcome, plies := tbOutcome(value)
+	return outcome, plies, true
+}
+
+// BestMove picks `player`'s move that keeps to the best outcome: the
+// quickest win, failing that a draw, or else the slowest loss. It returns
+// false if the position isn't covered.
+func (tb *Tablebase) BestMove(b *Board, player int) (*Move, Outcome, int, bool) {
+	if _, _, ok := tb.Probe(b, player); !ok {
+		return nil, OutcomeDraw, 0, false
+	}
+
+	var best *Move
+	bestOutcome, bestPlies := OutcomeLoss, -1
+	for _, move := range b.AllMoves(player) {
+		reply, plies, _ := tb.Probe(b.Apply(move), Opposition(player))
+		outcome := -reply
+		plies++
+		if outcome == OutcomeDraw {
+			plies = 0
+		}
+
+		better := outcome > bestOutcome ||
+			outcome == bestOutcome && outcome == OutcomeWin && plies < bestPlies ||
+			outcome == bestOutcome && outcome == OutcomeLoss && plies > bestPlies
+		if best == nil || better {
+			best, bestOutcome, bestPlies = move, outcome, plies
+		}
+	}
+
+	if best == nil {
+		return nil, OutcomeLoss, 0, true // No moves; we've lost
+	}
+	return best, bestOutcome, bestPlies, true
+}
+
+// Write saves the tablebase: a header, then each material's positions,
+// blocks and encoded values
+func (tb *Tablebase) Write(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	out.WriteString(tbMagic)
+	binary.Write(out, binary.LittleEndian, [2]uint32{uint32(tb.Pieces), uint32(len(tb.slices))})
+
+	for _, m := range materials(tb.Pieces) {
+		s, ok := tb.slices[m]
+		if !ok {
+			continue
+		}
+
+		header := []uint32{
+			uint32(m.men[0]), uint32(m.kings[0]), uint32(m.men[1]), uint32(m.kings[1]),
+			uint32(len(s.offsets)), uint32(len(s.data)),
+		}
+		binary.Write(out, binary.LittleEndian, header)
+		binary.Write(out, binary.LittleEndian, s.offsets)
+		out.Write(s.data)
+	}
+
+	return out.Flush()
+}
+
+// ReadTablebase loads a tablebase saved by Tablebase.Write
+func ReadTablebase(r io.Reader) (*Tablebase, error) {
+	in := bufio.NewReader(r)
+	magic := make([]byte, len(tbMagic))
+	if _, err := io.ReadFull(in, magic); err != nil || string(magic) != tbMagic {
+		return nil, fmt.Errorf("Not a tablebase")
+	}
+
+	var counts [2]uint32
+	if err := binary.Read(in, binary.LittleEndian, &counts); err != nil {
+		return nil, fmt.Errorf("Truncated tablebase: %s", err)
+	}
+
+	if counts[0] < 2 || counts[0] > 24 {
+		return nil, fmt.Errorf("Tablebase for %d pieces", counts[0])
+	}
+
+	tb := &Tablebase{Pieces: int(counts[0]), slices: map[material]*tbSlice{}}
+	for i := 0; i < int(counts[1]); i++ {
+		var header [6]uint32
+		if err := binary.Read(in, binary.LittleEndian, &header); err != nil {
+			return nil, fmt.Errorf("Truncated tablebase: %s", err)
+		}
+
+		m := material{
+			men:   [2]int{int(header[0]), int(header[2])},
+			kings: [2]int{int(header[1]), int(header[3])},
+		}
+		if header[0]+header[1]+header[2]+header[3] > counts[0] || m.men[0] > 12 || m.men[1] > 12 {
+			return nil, fmt.Errorf("Tablebase has %s, more than %d pieces", m, tb.Pieces)
+		}
+
+		s := &tbSlice{material: m, size: m.size()}
+		if uint64(header[4]) != (s.size+tbBlockSize-1)/tbBlockSize {
+			return nil, fmt.Errorf("Tablebase has %d blocks for %s", header[4], m)
+		}
+
+		s.offsets, s.data = make([]uint32, header[4]), make([]byte, header[5])
+		if err := binary.Read(in, binary.LittleEndian, s.offsets); err != nil {
+			return nil, fmt.Errorf("Truncated tablebase: %s", err)
+		}
+		if _, err := io.ReadFull(in, s.data); err != nil {
+			return nil, fmt.Errorf("Truncated tablebase: %s", err)
+		}
+		for j, offset := range s.offsets {
+			if offset >= header[5] || j > 0 && offset <= s.offsets[j-1] {
+				return nil, fmt.Errorf("Tablebase has a bad block for %s", m)
+			}
+		}
+		if !s.valid() {
+			return nil, fmt.Errorf("Tablebase has a bad block for %s", m)
+		}
+		tb.slices[m] = s
+	}
+
+	return tb, nil
+}
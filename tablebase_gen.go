@@ -0,0 +1,345 @@
+package draughts
+
+import "fmt"
+
+// Tablebases are generated by retrograde analysis, one material at a time,
+// working from the fewest pieces up. A position's successors either have
+// fewer pieces, after a capture, or fewer men, after a crowning, and those
+// are solved already; or they have the same material with the other player
+// to move, so a material is solved together with its mirror image.
+//
+// Within such a pair, positions are settled in order of how many plies
+// they are from the end. One with no moves is lost in 0. Once a position
+// is known to be lost in n, every position that can move to it is won in
+// n+1, unless it's already known to win quicker; once all of a position's
+// moves are known to lead to a win for the opponent, it's lost, as slowly
+// as it can be. Whatever is left unsettled is a draw.
+
+// materials lists every material with up to `pieces` pieces, at least one
+// for each side, in an order in which each can be solved: by the number of
+// pieces, then by the number of men
+func materials(pieces int) []material {
+	list := []material{}
+	for total := 2; total <= pieces; total++ {
+		for men := 0; men <= total; men++ {
+			for men0 := 0; men0 <= men; men0++ {
+				for kings0 := 0; kings0 <= total-men; kings0++ {
+					m := material{
+						men:   [2]int{men0, men - men0},
+						kings: [2]int{kings0, total - men - kings0},
+					}
+					if m.men[0]+m.kings[0] > 0 && m.men[1]+m.kings[1] > 0 {
+						list = append(list, m)
+					}
+				}
+			}
+		}
+	}
+
+	return list
+}
+
+// redChildren lists the positions Red's moves lead to, each turned round
+// so that the player to move is Red again, and says whether they're
+// captures. Where several captures end in the same position, it's listed
+// once for each.
+func (bb Bitboard) redChildren(children []Bitboard) ([]Bitboard, bool) {
+	children = children[:0]
+	if bb.CanCapture(1) {
+		for pieces := bb.Red; pieces != 0; pieces &= pieces - 1 {
+			bit := pieces & -pieces
+			bb.captureChildren(bit, bb.Kings&bit != 0, 0, &children)
+		}
+		return children, true
+	}
+
+	empty := bb.empty()
+	for pieces := bb.Red; pieces != 0; pieces &= pieces - 1 {
+		bit := pieces & -pieces
+		king := bb.Kings&bit != 0
+		for _, d := range diagonals(1, king) {
+			if to := step(bit, d) & empty; to != 0 {
+				next := bb
+				next.remove(bit)
+				next.place(1, to, king || to&crownMask(1) != 0)
+				children = append(children, next.flip())
+			}
+		}
+	}
+
+	return children, false
+}
+
+// captureChildren follows Red's captures from the piece on `bit`, as
+// Bitboard.jumps does, adding the positions they end in to `children`
+func (bb Bitboard) captureChildren(bit uint32, king bool, captured uint32, children *[]Bitboard) {
+	opp := bb.Green &^ captured
+	empty := bb.empty()
+
+	found := false
+	for _, d := range diagonals(1, king) {
+		over := step(bit, d) & opp
+		if over == 0 {
+			continue
+		}
+
+		land := step(over, d) & empty
+		if land == 0 {
+			continue
+		}
+
+		found = true
+		next := bb
+		next.remove(bit)
+		if !king && land&crownMask(1) != 0 {
+			next.place(1, land, true)
+			next.remove(captured | over)
+			*children = append(*children, next.flip())
+			continue
+		}
+
+		next.place(1, land, king)
+		next.captureChildren(land, king, captured|over, children)
+	}
+
+	if !found && captured != 0 {
+		final := bb
+		final.remove(captured)
+		*children = append(*children, final.flip())
+	}
+}
+
+// greenParents lists the positions from which Green's last move could have
+// led here, with Red to move, leaving the material as it is: moves that
+// neither capture nor crown. Each is turned round, so that Red is to move.
+func (bb Bitboard) greenParents(parents []Bitboard) []Bitboard {
+	parents = parents[:0]
+	empty := bb.empty()
+	for pieces := bb.Green; pieces != 0; pieces &= pieces - 1 {
+		bit := pieces & -pieces
+		king := bb.Kings&bit != 0
+
+		// Back the way Green came, which is the way Red goes
+		for _, d := range diagonals(1, king) {
+			from := step(bit, d) & empty
+			if from == 0 {
+				continue
+			}
+
+			prev := bb
+			prev.remove(bit)
+			prev.place(2, from, king)
+			if !prev.CanCapture(2) {
+				parents = append(parents, prev.flip())
+			}
+		}
+	}
+
+	return parents
+}
+
+// tbWork is what's known about the positions of one material while it's
+// being solved, by index
+type tbWork struct {
+	material material
+	value    []byte // The quickest win found so far, and once done the outcome
+	moves    []byte // Moves not yet known to lead to a win for the opponent
+	loss     []byte // Plies to the slowest loss through the moves that are
+	done     []bool
+	queue    [][]uint32 // Positions that may be settled, by plies
+}
+
+// settleAt notes that position `i` may be settled at `plies`
+func (w *tbWork) settleAt(plies int, i uint32) {
+	for len(w.queue) <= plies {
+		w.queue = append(w.queue, nil)
+	}
+	w.queue[plies] = append(w.queue[plies], i)
+}
+
+// GenerateTablebase works out the outcome of every English draughts
+// position with up to `pieces` pieces. If `progress` isn't nil, it's called
+// with a description of each material as it's solved.
+func GenerateTablebase(pieces int, progress func(string)) (*Tablebase, error) {
+	if pieces < 2 {
+		return nil, fmt.Errorf("A tablebase needs at least 2 pieces, not %d", pieces)
+	}
+
+	tb := &Tablebase{Pieces: pieces, slices: map[material]*tbSlice{}}
+	for _, m := range materials(pieces) {
+		if _, ok := tb.slices[m]; ok {
+			continue // Solved with its mirror image
+		}
+
+		// Most moves out of a material lead to one with the same number of
+		// pieces or one fewer, so only those are kept uncompressed
+		for _, s := range tb.slices {
+			if s.values != nil && s.material.pieces() < m.pieces()-1 {
+				s.values = nil
+			}
+		}
+
+		pair := []material{m}
+		if m.flip() != m {
+			pair = append(pair, m.flip())
+		}
+
+		values, err := tb.solve(pair)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, m := range pair {
+			s := newSlice(m, values[i])
+			s.values = values[i]
+			tb.slices[m] = s
+			if progress != nil {
+				progress(m.String())
+			}
+		}
+	}
+
+	for _, s := range tb.slices {
+		s.values = nil
+	}
+	return tb, nil
+}
+
+// solve works out the values of all the positions of a material and its
+// mirror image, given every material they can lead to
+func (tb *Tablebase) solve(pair []material) ([][]byte, error) {
+	work := make([]*tbWork, len(pair))
+	for k, m := range pair {
+		size := m.size()
+		work[k] = &tbWork{
+			material: m,
+			value:    make([]byte, size),
+			moves:    make([]byte, size),
+			loss:     make([]byte, size),
+			done:     make([]bool, size),
+		}
+	}
+
+	// other is the material of the positions one move on
+	other := func(k int) *tbWork {
+		return work[len(work)-1-k]
+	}
+
+	// First the moves that lead out of the pair
+	last := 0
+	var children []Bitboard
+	for k, w := range work {
+		for i := range w.done {
+			bb, ok := w.material.position(uint64(i))
+			if !ok {
+				w.done[i] = true
+				continue
+			}
+
+			var capture bool
+			children, capture = bb.redChildren(children)
+			for _, child := range children {
+				if !capture && materialOf(child) == other(k).material {
+					w.moves[i]++
+					continue
+				}
+
+				value, ok := tb.lookup(child)
+				if !ok {
+					return nil, fmt.Errorf("Can't solve %s before %s", w.material, materialOf(child))
+				}
+
+				outcome, plies := tbOutcome(value)
+				switch outcome {
+				case OutcomeLoss:
+					if w.value[i] == 0 || int(w.value[i]) > plies+2 {
+						w.value[i] = tbValue(plies + 1)
+					}
+				case OutcomeWin:
+					w.loss[i] = max(w.loss[i], byte(plies+1))
+				default:
+					w.moves[i]++ // A draw, so never a loss
+				}
+				last = max(last, plies+1)
+			}
+		}
+	}
+
+	for _, w := range work {
+		for i, done := range w.done {
+			switch {
+			case done:
+			case w.value[i] != 0:
+				w.settleAt(int(w.value[i])-1, uint32(i))
+			case w.moves[i] == 0:
+				w.settleAt(int(w.loss[i]), uint32(i))
+			}
+		}
+	}
+
+	// Then settle positions a ply at a time, passing on what each means for
+	// the positions leading to it
+	var parents []Bitboard
+	for plies := 0; plies <= last; plies++ {
+		if plies >= tbMaxPlies {
+			return nil, fmt.Errorf("%s has positions more than %d plies from the end", pair[0], tbMaxPlies)
+		}
+
+		for k, w := range work {
+			if plies >= len(w.queue) {
+				continue
+			}
+
+			for _, i := range w.queue[plies] {
+				if w.done[i] {
+					continue
+				}
+
+				lost := w.value[i] == 0
+				w.done[i] = true
+				w.value[i] = tbValue(plies)
+
+				bb, _ := w.material.position(uint64(i))
+				prev := other(k)
+				parents = bb.greenParents(parents)
+				for _, parent := range parents {
+					j := prev.material.index(parent)
+					switch {
+					case prev.done[j]:
+					case lost:
+						if prev.value[j] == 0 || int(prev.value[j]) > plies+2 {
+							prev.value[j] = tbValue(plies + 1)
+							prev.settleAt(plies+1, uint32(j))
+						}
+					default:
+						prev.moves[j]--
+						prev.loss[j] = max(prev.loss[j], byte(plies+1))
+						if prev.moves[j] == 0 && prev.value[j] == 0 {
+							prev.settleAt(int(prev.loss[j]), uint32(j))
+						}
+					}
+				}
+				last = max(last, plies+1)
+			}
+			w.queue[plies] = nil
+		}
+	}
+
+	values := make([][]byte, len(work))
+	for k, w := range work {
+		for i, done := range w.done {
+			if !done {
+				w.value[i] = 0 // Drawn
+			}
+
+			// Impossible positions are never looked up, so they may as well
+			// carry on the run before them
+			if _, ok := w.material.position(uint64(i)); !ok && i > 0 {
+				w.value[i] = w.value[i-1]
+			}
+		}
+		values[k] = w.value
+	}
+
+	return values, nil
+}
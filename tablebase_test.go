@@ -0,0 +1,209 @@
+package draughts
+
+import (
+	"bytes"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// testTablebase is generated once, as it takes a moment
+var (
+	testTablebase     *Tablebase
+	testTablebaseOnce sync.Once
+)
+
+func tablebase(t *testing.T) *Tablebase {
+	testTablebaseOnce.Do(func() {
+		tb, err := GenerateTablebase(4, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testTablebase = tb
+	})
+
+	if testTablebase == nil {
+		t.Fatal("No tablebase")
+	}
+	return testTablebase
+}
+
+// bitboardBoard sets up an English board holding the pieces of `bb`
+func bitboardBoard(bb Bitboard) *Board {
+	b := &Board{}
+	for squares := bb.Red | bb.Green; squares != 0; squares &= squares - 1 {
+		bit := squares & -squares
+		piece := 1
+		if bb.Green&bit != 0 {
+			piece = 2
+		}
+		if bb.Kings&bit != 0 {
+			piece = -piece
+		}
+		b.Set(bitPos(bit), piece)
+	}
+
+	return b
+}
+
+func TestTablebaseIndex(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, m := range materials(5) {
+		size := m.size()
+		for n := 0; n < 200; n++ {
+			index := uint64(rng.Int63n(int64(size)))
+			bb, ok := m.position(index)
+			if !ok {
+				continue
+			}
+
+			if materialOf(bb) != m {
+				t.Fatalf("%s: position %d has %s", m, index, materialOf(bb))
+			}
+			if again := m.index(bb); again != index {
+				t.Fatalf("%s: position %d came back as %d", m, index, again)
+			}
+			if bb.Red&lastRow&^bb.Kings != 0 || bb.Green&firstRow&^bb.Kings != 0 {
+				t.Fatalf("%s: position %d has a man on the crowning row", m, index)
+			}
+		}
+	}
+
+	if bb := (Bitboard{Red: 1, Green: 1 << 31, Kings: 1 << 31}); bb.flip().flip() != bb {
+		t.Errorf("Turning the board round twice should leave it as it was")
+	}
+}
+
+func TestTablebaseConsistent(t *testing.T) {
+	tb := tablebase(t)
+	rng := rand.New(rand.NewSource(2))
+
+	// Every value agrees with the values of the moves from the position
+	for _, m := range materials(tb.Pieces) {
+		for n := 0; n < 300; n++ {
+			bb, ok := m.position(uint64(rng.Int63n(int64(m.size()))))
+			if !ok {
+				continue
+			}
+			board := bitboardBoard(bb)
+
+			outcome, plies, ok := tb.Probe(board, 1)
+			if !ok {
+				t.Fatalf("%s: position not found", m)
+			}
+
+			expected, expectedPlies := OutcomeLoss, 0
+			for _, move := range board.AllMoves(1) {
+				reply, replyPlies, ok := tb.Probe(board.Apply(move), 2)
+				if !ok {
+					t.Fatalf("%s: position after %s not found", m, board.MoveString(move))
+				}
+
+				switch {
+				case reply == OutcomeLoss && (expected != OutcomeWin || replyPlies+1 < expectedPlies):
+					expected, expectedPlies = OutcomeWin, replyPlies+1
+				case reply == OutcomeDraw && expected == OutcomeLoss:
+					expected, expectedPlies = OutcomeDraw, 0
+				case reply == OutcomeWin && expected == OutcomeLoss:
+					expectedPlies = max(expectedPlies, replyPlies+1)
+				}
+			}
+
+			if outcome != expected || plies != expectedPlies {
+				t.Fatalf("%s: %s: expected %s in %d, found %s in %d",
+					m, board.FEN(1), expected, expectedPlies, outcome, plies)
+			}
+		}
+	}
+}
+
+func TestTablebaseKnownPositions(t *testing.T) {
+	tb := tablebase(t)
+	tests := []struct {
+		fen     string
+		outcome Outcome
+	}{
+		{"W:WK1,K5:BK32", OutcomeWin},  // Two kings beat one
+		{"B:WK1,K5:BK32", OutcomeLoss}, // Even with the move
+		{"W:WK5:BK32", OutcomeDraw},    // One king can't catch another
+		{"B:W14:B10", OutcomeWin},      // Capture, then crown
+	}
+
+	for _, test := range tests {
+		board, player, err := ParseFEN(English, test.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		outcome, plies, ok := tb.Probe(board, player)
+		if !ok || outcome != test.outcome {
+			t.Errorf("%s: expected a %s, found %s in %d", test.fen, test.outcome, outcome, plies)
+		}
+	}
+
+	// Too many pieces, or the wrong rules
+	board, player, _ := ParseFEN(English, "W:W29,30,31,32:B1,2")
+	if _, _, ok := tb.Probe(board, player); ok {
+		t.Errorf("Expected a position with too many pieces not to be found")
+	}
+	board, player, _ = ParseFEN(International, "W:WK46:BK5")
+	if _, _, ok := tb.Probe(board, player); ok {
+		t.Errorf("Expected International draughts not to be found")
+	}
+}
+
+func TestTablebasePlaysPerfectly(t *testing.T) {
+	tb := tablebase(t)
+	board, player, _ := ParseFEN(English, "W:WK1,K5:BK32")
+	_, plies, _ := tb.Probe(board, player)
+
+	// Playing best moves for both sides, the win comes as promised
+	for n := 0; n < plies; n++ {
+		move, _, _, ok := tb.BestMove(board, player)
+		if !ok || move == nil {
+			t.Fatalf("No move after %d plies", n)
+		}
+		board, player = board.Apply(move), Opposition(player)
+	}
+
+	if moves := board.AllMoves(player); len(moves) != 0 || player != 1 {
+		t.Errorf("Expected Red to have lost after %d plies: %s", plies, board.FEN(player))
+	}
+}
+
+func TestTablebaseReadWrite(t *testing.T) {
+	tb, err := GenerateTablebase(3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := tb.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	again, err := ReadTablebase(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if again.Pieces != 3 || len(again.slices) != len(tb.slices) {
+		t.Fatalf("Expected %d materials, found %d", len(tb.slices), len(again.slices))
+	}
+	for m, s := range tb.slices {
+		for i := uint64(0); i < s.size; i += 7 {
+			if again.slices[m].get(i) != s.get(i) {
+				t.Fatalf("%s: position %d differs", m, i)
+			}
+		}
+	}
+
+	for _, bad := range [][]byte{nil, []byte("DRTB"), buf.Bytes()[:len(buf.Bytes())-1]} {
+		if _, err := ReadTablebase(bytes.NewReader(bad)); err == nil {
+			t.Errorf("Expected an error reading %d bytes", len(bad))
+		}
+	}
+
+	if _, err := GenerateTablebase(1, nil); err == nil {
+		t.Errorf("Expected an error generating a 1 piece tablebase")
+	}
+}
@@ -0,0 +1,638 @@
+package draughts
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// TOML (https://toml.io) is read into the same kinds of values as JSON is
+// by encoding/json: map[string]interface{} for tables, []interface{} for
+// arrays, string, bool and float64, except that integers are int64. Dates
+// and times are the one part of the language left out, as nothing we read
+// has any use for them.
+
+// tomlParser reads a TOML document
+type tomlParser struct {
+	text string
+	pos  int
+	line int
+
+	root    map[string]interface{}
+	current map[string]interface{} // The table key/value pairs go into
+
+	// defined says how each table, by address, was defined, if not just
+	// as the parent of another
+	defined map[string]tableDefinition
+}
+
+// tableDefinition is how a table was defined, which limits how it can be
+// added to. One with a [header], or written inline, can't be added to at
+// all; one made by dotted keys only by more dotted keys.
+type tableDefinition int
+
+const (
+	definedByHeader tableDefinition = iota + 1
+	definedByDots
+)
+
+// tableArray is an [[array of tables]], while it's being read: arrays
+// written as values can't be added to, so they need telling apart
+type tableArray struct {
+	tables []map[string]interface{}
+}
+
+// parseTOML reads a TOML document
+func parseTOML(data []byte) (map[string]interface{}, error) {
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("TOML must be UTF-8")
+	}
+
+	p := &tomlParser{text: string(data), line: 1, root: map[string]interface{}{}, defined: map[string]tableDefinition{}}
+	p.current = p.root
+	if err := p.document(); err != nil {
+		return nil, fmt.Errorf("Line %d: %s", p.line, err)
+	}
+
+	finishTables(p.root)
+	return p.root, nil
+}
+
+// finishTables turns the arrays of tables in `table`, and in the tables in
+// it, into plain arrays
+func finishTables(table map[string]interface{}) {
+	for key, value := range table {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			finishTables(v)
+		case *tableArray:
+			list := []interface{}{}
+			for _, t := range v.tables {
+				finishTables(t)
+				list = append(list, t)
+			}
+			table[key] = list
+		}
+	}
+}
+
+// tableKey identifies a table, for tomlParser.defined
+func tableKey(table map[string]interface{}) string {
+	return fmt.Sprintf("%p", table)
+}
+
+func (p *tomlParser) done() bool {
+	return p.pos >= len(p.text)
+}
+
+// peek returns the next byte, or 0 at the end
+func (p *tomlParser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.text[p.pos]
+}
+
+// skipSpace skips spaces and tabs
+func (p *tomlParser) skipSpace() {
+	for p.peek() == ' ' || p.peek() == '\t' {
+		p.pos++
+	}
+}
+
+// endOfLine skips any comment, and the newline ending the line
+func (p *tomlParser) endOfLine() error {
+	p.skipSpace()
+	if p.peek() == '#' {
+		for !p.done() && p.peek() != '\n' {
+			if c := p.peek(); c < ' ' && c != '\t' && c != '\r' {
+				return fmt.Errorf("Control character in a comment")
+			}
+			p.pos++
+		}
+	}
+
+	switch {
+	case p.done():
+		return nil
+	case strings.HasPrefix(p.text[p.pos:], "\r\n"):
+		p.pos += 2
+	case p.peek() == '\n':
+		p.pos++
+	default:
+		return fmt.Errorf("Expected the end of the line, found '%c'", p.peek())
+	}
+	p.line++
+	return nil
+}
+
+// document reads the lines of the document: blank, comments, [tables],
+// [[arrays of tables]] and key = value pairs
+func (p *tomlParser) document() error {
+	for !p.done() {
+		p.skipSpace()
+
+		var err error
+		switch {
+		case strings.HasPrefix(p.text[p.pos:], "[["):
+			p.pos += 2
+			err = p.tableHeader(true)
+		case p.peek() == '[':
+			p.pos++
+			err = p.tableHeader(false)
+		case p.peek() != '#' && p.peek() != '\n' && p.peek() != '\r' && !p.done():
+			err = p.keyValue(p.current)
+		}
+		if err == nil {
+			err = p.endOfLine()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tableHeader reads the rest of a [table] or [[array of tables]] header
+// and makes its table the current one
+func (p *tomlParser) tableHeader(array bool) error {
+	p.skipSpace()
+	keys, err := p.key()
+	if err != nil {
+		return err
+	}
+
+	closing := "]"
+	if array {
+		closing = "]]"
+	}
+	p.skipSpace()
+	if !strings.HasPrefix(p.text[p.pos:], closing) {
+		return fmt.Errorf("Expected '%s' after the table name", closing)
+	}
+	p.pos += len(closing)
+
+	name := strings.Join(keys, ".")
+	table, err := p.table(p.root, keys[:len(keys)-1], false)
+	if err != nil {
+		return fmt.Errorf("[%s]: %s", name, err)
+	}
+	last := keys[len(keys)-1]
+
+	if array {
+		list, ok := table[last].(*tableArray)
+		if !ok {
+			if _, exists := table[last]; exists {
+				return fmt.Errorf("[[%s]] isn't an array of tables", name)
+			}
+			list = &tableArray{}
+			table[last] = list
+		}
+		p.current = map[string]interface{}{}
+		list.tables = append(list.tables, p.current)
+		return nil
+	}
+
+	switch existing := table[last].(type) {
+	case nil:
+		p.current = map[string]interface{}{}
+		table[last] = p.current
+	case map[string]interface{}:
+		if p.defined[tableKey(existing)] != 0 {
+			return fmt.Errorf("Table [%s] is already defined", name)
+		}
+		p.current = existing
+	default:
+		return fmt.Errorf("[%s] is already a value", name)
+	}
+	p.defined[tableKey(p.current)] = definedByHeader
+	return nil
+}
+
+// table finds the table `keys` leads to from `from`, creating any tables
+// on the way. Arrays of tables lead to their last table. A header can lead
+// anywhere, but dotted keys, `dotted`, only into tables they made.
+func (p *tomlParser) table(from map[string]interface{}, keys []string, dotted bool) (map[string]interface{}, error) {
+	table := from
+	for _, key := range keys {
+		switch next := table[key].(type) {
+		case nil:
+			created := map[string]interface{}{}
+			if dotted {
+				p.defined[tableKey(created)] = definedByDots
+			}
+			table[key] = created
+			table = created
+		case map[string]interface{}:
+			if dotted && p.defined[tableKey(next)] != definedByDots {
+				return nil, fmt.Errorf("Table '%s' is already defined", key)
+			}
+			table = next
+		case *tableArray:
+			if dotted {
+				return nil, fmt.Errorf("'%s' is an array of tables", key)
+			}
+			table = next.tables[len(next.tables)-1]
+		default:
+			return nil, fmt.Errorf("'%s' is already a value", key)
+		}
+	}
+
+	return table, nil
+}
+
+// keyValue reads a key = value pair into `table`
+func (p *tomlParser) keyValue(table map[string]interface{}) error {
+	keys, err := p.key()
+	if err != nil {
+		return err
+	}
+
+	p.skipSpace()
+	if p.peek() != '=' {
+		return fmt.Errorf("Expected '=' after '%s'", strings.Join(keys, "."))
+	}
+	p.pos++
+	p.skipSpace()
+
+	value, err := p.value()
+	if err != nil {
+		return err
+	}
+
+	parent, err := p.table(table, keys[:len(keys)-1], true)
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if _, exists := parent[last]; exists {
+		return fmt.Errorf("'%s' is already defined", strings.Join(keys, "."))
+	}
+	parent[last] = value
+	return nil
+}
+
+// key reads a key, which may be dotted: bare keys and quoted ones, joined
+// by dots
+func (p *tomlParser) key() ([]string, error) {
+	keys := []string{}
+	for {
+		p.skipSpace()
+
+		var key string
+		var err error
+		switch c := p.peek(); {
+		case c == '"':
+			key, err = p.basicString()
+		case c == '\'':
+			key, err = p.literalString()
+		default:
+			start := p.pos
+			for c := p.peek(); c == '_' || c == '-' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9'); c = p.peek() {
+				p.pos++
+			}
+			if p.pos == start {
+				return nil, fmt.Errorf("Expected a key, found '%c'", c)
+			}
+			key = p.text[start:p.pos]
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+
+		p.skipSpace()
+		if p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+// value reads a value of any kind
+func (p *tomlParser) value() (interface{}, error) {
+	switch c := p.peek(); {
+	case strings.HasPrefix(p.text[p.pos:], `"""`):
+		return p.multilineString(`"""`)
+	case strings.HasPrefix(p.text[p.pos:], `'''`):
+		return p.multilineString(`'''`)
+	case c == '"':
+		return p.basicString()
+	case c == '\'':
+		return p.literalString()
+	case c == '[':
+		return p.array()
+	case c == '{':
+		return p.inlineTable()
+	case strings.HasPrefix(p.text[p.pos:], "true"):
+		p.pos += 4
+		return true, nil
+	case strings.HasPrefix(p.text[p.pos:], "false"):
+		p.pos += 5
+		return false, nil
+	}
+
+	return p.number()
+}
+
+// basicString reads a "string", with escapes
+func (p *tomlParser) basicString() (string, error) {
+	p.pos++
+	var sb strings.Builder
+	for {
+		if p.done() || p.peek() == '\n' {
+			return "", fmt.Errorf("Unterminated string")
+		}
+
+		c := p.peek()
+		switch {
+		case c == '"':
+			p.pos++
+			return sb.String(), nil
+		case c == '\\':
+			if err := p.escape(&sb); err != nil {
+				return "", err
+			}
+		case c < ' ' && c != '\t' || c == 0x7f:
+			return "", fmt.Errorf("Control character in a string")
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// escape reads an escape sequence in a basic string
+func (p *tomlParser) escape(sb *strings.Builder) error {
+	p.pos++
+	c := p.peek()
+	p.pos++
+
+	simple := map[byte]string{'b': "\b", 't': "\t", 'n': "\n", 'f': "\f", 'r': "\r", 'e': "\x1b", '"': `"`, '\\': `\`}
+	if s, ok := simple[c]; ok {
+		sb.WriteString(s)
+		return nil
+	}
+
+	digits := map[byte]int{'x': 2, 'u': 4, 'U': 8}[c]
+	if digits == 0 || p.pos+digits > len(p.text) {
+		return fmt.Errorf("Bad escape '\\%c'", c)
+	}
+	hex := p.text[p.pos : p.pos+digits]
+	code, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || !utf8.ValidRune(rune(code)) {
+		return fmt.Errorf("Bad escape '\\%c%s'", c, hex)
+	}
+	p.pos += digits
+	sb.WriteRune(rune(code))
+	return nil
+}
+
+// literalString reads a 'string', without escapes
+func (p *tomlParser) literalString() (string, error) {
+	p.pos++
+	start := p.pos
+	for p.peek() != '\'' {
+		if c := p.peek(); p.done() || c == '\n' {
+			return "", fmt.Errorf("Unterminated string")
+		} else if c < ' ' && c != '\t' || c == 0x7f {
+			return "", fmt.Errorf("Control character in a string")
+		}
+		p.pos++
+	}
+
+	p.pos++
+	return p.text[start : p.pos-1], nil
+}
+
+// multilineString reads a string over several lines, between `quotes`:
+// three double quotes for a basic string, three single quotes for a literal
+// one. A newline straight after the opening quotes isn't part of the
+// string, and in a basic string a backslash at the end of a line joins it
+// to the next non-blank one.
+func (p *tomlParser) multilineString(quotes string) (string, error) {
+	p.pos += 3
+	if strings.HasPrefix(p.text[p.pos:], "\r\n") {
+		p.pos += 2
+		p.line++
+	} else if p.peek() == '\n' {
+		p.pos++
+		p.line++
+	}
+
+	var sb strings.Builder
+	for {
+		if p.done() {
+			return "", fmt.Errorf("Unterminated string")
+		}
+
+		// Up to two quotes may end the string, straight before the closing
+		// ones
+		if strings.HasPrefix(p.text[p.pos:], quotes) {
+			extra := 0
+			for extra < 2 && p.pos+3+extra < len(p.text) && p.text[p.pos+3+extra] == quotes[0] {
+				extra++
+			}
+			sb.WriteString(p.text[p.pos : p.pos+extra])
+			p.pos += 3 + extra
+			return sb.String(), nil
+		}
+
+		c := p.peek()
+		switch {
+		case c == '\\' && quotes == `"""`:
+			rest := strings.TrimLeft(p.text[p.pos+1:], " \t")
+			if strings.HasPrefix(rest, "\n") || strings.HasPrefix(rest, "\r\n") {
+				// A line ending backslash
+				p.pos++
+				for c := p.peek(); c == ' ' || c == '\t' || c == '\r' || c == '\n'; c = p.peek() {
+					if c == '\n' {
+						p.line++
+					}
+					p.pos++
+				}
+				continue
+			}
+			if err := p.escape(&sb); err != nil {
+				return "", err
+			}
+		case c == '\n':
+			sb.WriteByte(c)
+			p.pos++
+			p.line++
+		case c < ' ' && c != '\t' && c != '\r' || c == 0x7f:
+			return "", fmt.Errorf("Control character in a string")
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// skipBlank skips whitespace, newlines and comments, as allowed between
+// the values of an array
+func (p *tomlParser) skipBlank() error {
+	for {
+		p.skipSpace()
+		if c := p.peek(); c != '#' && c != '\n' && c != '\r' {
+			return nil
+		}
+		if err := p.endOfLine(); err != nil {
+			return err
+		}
+	}
+}
+
+// array reads an [array], which may run over several lines
+func (p *tomlParser) array() ([]interface{}, error) {
+	p.pos++
+	values := []interface{}{}
+	for {
+		if err := p.skipBlank(); err != nil {
+			return nil, err
+		}
+		if p.peek() == ']' {
+			p.pos++
+			return values, nil
+		}
+
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		if err := p.skipBlank(); err != nil {
+			return nil, err
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, fmt.Errorf("Expected ',' or ']' in an array")
+		}
+	}
+}
+
+// inlineTable reads an { inline = table }, all on one line
+func (p *tomlParser) inlineTable() (map[string]interface{}, error) {
+	p.pos++
+	table := map[string]interface{}{}
+	p.skipSpace()
+	if p.peek() == '}' {
+		p.pos++
+		p.defined[tableKey(table)] = definedByHeader
+		return table, nil
+	}
+
+	for {
+		if err := p.keyValue(table); err != nil {
+			return nil, err
+		}
+
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			p.fixInline(table)
+			return table, nil
+		default:
+			return nil, fmt.Errorf("Expected ',' or '}' in an inline table")
+		}
+	}
+}
+
+// fixInline marks an inline table, and those in it, as complete
+func (p *tomlParser) fixInline(table map[string]interface{}) {
+	p.defined[tableKey(table)] = definedByHeader
+	for _, value := range table {
+		if t, ok := value.(map[string]interface{}); ok {
+			p.fixInline(t)
+		}
+	}
+}
+
+// number reads an integer or a float
+func (p *tomlParser) number() (interface{}, error) {
+	start := p.pos
+	for c := p.peek(); c == '_' || c == '+' || c == '-' || c == '.' || c == ':' ||
+		(c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z'); c = p.peek() {
+		p.pos++
+	}
+	text := p.text[start:p.pos]
+	if text == "" {
+		return nil, fmt.Errorf("Expected a value, found '%c'", p.peek())
+	}
+
+	unsigned := strings.TrimLeft(text, "+-")
+	if len(unsigned) < len(text)-1 {
+		return nil, fmt.Errorf("Bad number '%s'", text)
+	}
+	switch unsigned {
+	case "inf":
+		return math.Inf(map[bool]int{true: -1, false: 1}[text[0] == '-']), nil
+	case "nan":
+		return math.NaN(), nil
+	}
+
+	if strings.Contains(text, ":") || (len(text) > 4 && text[4] == '-' && !strings.ContainsAny(text[:4], "+-")) {
+		return nil, fmt.Errorf("Dates and times aren't supported")
+	}
+
+	// Underscores only go between digits
+	for i := range text {
+		if text[i] == '_' && (i == 0 || i == len(text)-1 || !isHexDigit(text[i-1]) || !isHexDigit(text[i+1])) {
+			return nil, fmt.Errorf("Bad number '%s'", text)
+		}
+	}
+	digits := strings.ReplaceAll(text, "_", "")
+
+	if len(unsigned) > 1 && unsigned[0] == '0' && strings.ContainsAny(unsigned[1:2], "xob") {
+		if len(unsigned) != len(text) {
+			return nil, fmt.Errorf("Bad number '%s'", text)
+		}
+		base := map[byte]int{'x': 16, 'o': 8, 'b': 2}[unsigned[1]]
+		n, err := strconv.ParseInt(digits[2:], base, 64)
+		if err != nil || strings.HasPrefix(digits[2:], "+") || strings.HasPrefix(digits[2:], "-") {
+			return nil, fmt.Errorf("Bad number '%s'", text)
+		}
+		return n, nil
+	}
+
+	// No leading zeros, as in 012, and digits either side of any point
+	whole := strings.TrimLeft(digits, "+-")
+	if end := strings.IndexAny(whole, ".eE"); end >= 0 {
+		whole = whole[:end]
+	}
+	if whole == "" || (len(whole) > 1 && whole[0] == '0') {
+		return nil, fmt.Errorf("Bad number '%s'", text)
+	}
+
+	if !strings.ContainsAny(digits, ".eE") {
+		n, err := strconv.ParseInt(digits, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Bad integer '%s'", text)
+		}
+		return n, nil
+	}
+
+	if i := strings.Index(digits, "."); i >= 0 && (i+1 == len(digits) || digits[i+1] < '0' || digits[i+1] > '9') {
+		return nil, fmt.Errorf("Bad float '%s'", text)
+	}
+	f, err := strconv.ParseFloat(digits, 64)
+	if err != nil {
+		return nil, fmt.Errorf("Bad float '%s'", text)
+	}
+	return f, nil
+}
+
+// isHexDigit is true for the digits of any base TOML allows
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
@@ -0,0 +1,143 @@
+package draughts
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	text := `# A comment
+title = "Weights \"and\" things\t\u00e9" # Another
+'literal key' = 'C:\no\escapes'
+"quoted.key" = 1
+dotted.key = 2
+dotted . more = -3
+ints = [ 1_000, +7, 0xff, 0o17, 0b101, ]
+floats = [1.5, -2e3, 6.25E-1]
+bools = [true, false]
+nested = [[1, 2], ["a"]] # Arrays of anything
+inline = { x = 1, y.z = "two" }
+multiline = [
+  1, # First
+  2,
+]
+poem = """
+Roses are red \
+    and \"violets\" ""are"" blue"""
+raw = '''
+Lines
+stay'''
+positive = +inf
+
+[table]
+key = "value"
+
+[table.sub]
+key = 1
+
+[a.b.c]
+deep = true
+
+[a]
+shallow = true
+
+[[array]]
+name = "first"
+
+[[array]]
+name = "second"
+
+[array.sub]
+x = 1
+`
+	doc, err := parseTOML([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"title":       "Weights \"and\" things\té",
+		"literal key": `C:\no\escapes`,
+		"quoted.key":  int64(1),
+		"dotted":      map[string]interface{}{"key": int64(2), "more": int64(-3)},
+		"ints":        []interface{}{int64(1000), int64(7), int64(255), int64(15), int64(5)},
+		"floats":      []interface{}{1.5, -2000.0, 0.625},
+		"bools":       []interface{}{true, false},
+		"nested":      []interface{}{[]interface{}{int64(1), int64(2)}, []interface{}{"a"}},
+		"inline":      map[string]interface{}{"x": int64(1), "y": map[string]interface{}{"z": "two"}},
+		"multiline":   []interface{}{int64(1), int64(2)},
+		"poem":        `Roses are red and "violets" ""are"" blue`,
+		"raw":         "Lines\nstay",
+		"positive":    math.Inf(1),
+		"table":       map[string]interface{}{"key": "value", "sub": map[string]interface{}{"key": int64(1)}},
+		"a": map[string]interface{}{
+			"shallow": true,
+			"b":       map[string]interface{}{"c": map[string]interface{}{"deep": true}},
+		},
+		"array": []interface{}{
+			map[string]interface{}{"name": "first"},
+			map[string]interface{}{"name": "second", "sub": map[string]interface{}{"x": int64(1)}},
+		},
+	}
+	for key, value := range expected {
+		if !reflect.DeepEqual(doc[key], value) {
+			t.Errorf("%s: expected %#v, found %#v", key, value, doc[key])
+		}
+	}
+	if len(doc) != len(expected) {
+		t.Errorf("Expected %d keys, found %d", len(expected), len(doc))
+	}
+
+	if doc, err := parseTOML([]byte("nan = nan\r\n")); err != nil || !math.IsNaN(doc["nan"].(float64)) {
+		t.Errorf("Expected nan, found %v (%v)", doc["nan"], err)
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	bad := []string{
+		"key",
+		"key = ",
+		"= 1",
+		"key = 1 2",
+		"key = 1\nkey = 2",
+		`key = "unterminated`,
+		`key = "bad \q escape"`,
+		"key = 'new\nline'",
+		`key = """never ends`,
+		"key = [1, 2",
+		"key = [1 2]",
+		"key = { a = 1",
+		"key = { a = 1, a = 2 }",
+		"key = 012",
+		"key = 1__0",
+		"key = _1",
+		"key = 1.",
+		"key = .5",
+		"key = +0x10",
+		"key = 0xgg",
+		"key = 99999999999999999999",
+		"key = 1979-05-27",
+		"key = 07:32:00",
+		"key = yes",
+		"[table]\n[table]",
+		"[table\nkey = 1",
+		"[[array]\n",
+		"key = 1\n[key]",
+		"key = [1]\n[[key]]",
+		"[table]\nkey = 1\n[[table]]",
+		"a.b = 1\n[a]",
+		"[a.b]\n[a]\nb.c = 1",
+		"inline = { x = 1 }\n[inline]",
+		"inline = { x = 1 }\ninline.y = 2",
+		"key = \"\x01\"",
+		"key = 1 # \x01",
+		"\xff = 1",
+	}
+
+	for _, text := range bad {
+		if doc, err := parseTOML([]byte(text)); err == nil {
+			t.Errorf("Expected an error parsing %q, found %v", text, doc)
+		}
+	}
+}
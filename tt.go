@@ -0,0 +1,129 @@
+package draughts
+
+import "sync"
+
+// Bound says how a stored value relates to the true value of a position,
+// depending on whether the search that produced it failed low, failed high
+// or found an exact value inside the alpha-beta window
+type Bound uint8
+
+// Bound types
+const (
+	BoundNone  Bound = iota
+	BoundExact       // Value is exact
+	BoundLower       // Search failed high: true value >= Value
+	BoundUpper       // Search failed low: true value <= Value
+)
+
+// TTEntry is what we remember about a searched position
+type TTEntry struct {
+	Key   uint64
+	Move  *Move // Best move found, used to order moves when revisited
+	Value int
+	Depth int
+	Bound Bound
+}
+
+// ttLocks is the number of locks guarding a table's slots, each lock a
+// stripe of them, so that searches on several goroutines rarely wait on
+// each other
+const ttLocks = 1 << 10
+
+// TranspositionTable is a fixed size, hash-indexed cache of search results,
+// so that positions reached via different move orders are searched once.
+// It's safe to share between goroutines, as a parallel search does.
+type TranspositionTable struct {
+	entries []TTEntry
+	mask    uint64
+	locks   [ttLocks]sync.Mutex
+}
+
+// lock locks and returns the lock for the slot of `key`
+func (tt *TranspositionTable) lock(key uint64) *sync.Mutex {
+	l := &tt.locks[key&tt.mask&(ttLocks-1)]
+	l.Lock()
+	return l
+}
+
+// NewTranspositionTable allocates a table with room for at least `size`
+// entries, rounded up to a power of two
+func NewTranspositionTable(size int) *TranspositionTable {
+	n := 1
+	for n < size {
+		n <<= 1
+	}
+
+	return &TranspositionTable{
+		entries: make([]TTEntry, n),
+		mask:    uint64(n - 1),
+	}
+}
+
+// Probe looks up `key`, returning the entry if it's there
+func (tt *TranspositionTable) Probe(key uint64) (TTEntry, bool) {
+	l := tt.lock(key)
+	entry := tt.entries[key&tt.mask]
+	l.Unlock()
+
+	if entry.Bound == BoundNone || entry.Key != key {
+		return TTEntry{}, false
+	}
+
+	return entry, true
+}
+
+// Store records a search result. A different position in the same slot is
+// always replaced, but for the same position we keep the deeper result.
+func (tt *TranspositionTable) Store(key uint64, depth, value int, bound Bound, move *Move) {
+	defer tt.lock(key).Unlock()
+
+	slot := &tt.entries[key&tt.mask]
+	if slot.Key == key && slot.Bound != BoundNone && slot.Depth > depth {
+		return
+	}
+
+	*slot = TTEntry{
+		Key:   key,
+		Move:  move,
+		Value: value,
+		Depth: depth,
+		Bound: bound,
+	}
+}
+
+// Clear empties the table
+func (tt *TranspositionTable) Clear() {
+	for i := range tt.locks {
+		tt.locks[i].Lock()
+	}
+	for i := range tt.entries {
+		tt.entries[i] = TTEntry{}
+	}
+	for i := range tt.locks {
+		tt.locks[i].Unlock()
+	}
+}
+
+// valueToTT converts a win/loss value, which counts plies from the root,
+// to one counting from the current node, as it may be found again at a
+// different ply
+func valueToTT(value, ply int) int {
+	if value >= WinScore-winRange {
+		return value + ply
+	}
+	if value <= -WinScore+winRange {
+		return value - ply
+	}
+	return value
+}
+
+// valueFromTT does the opposite of valueToTT
+func valueFromTT(value, ply int) int {
+	if value >= WinScore-winRange {
+		return value - ply
+	}
+	if value <= -WinScore+winRange {
+		return value + ply
+	}
+	return value
+}
@@ -0,0 +1,109 @@
+package draughts
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestTranspositionTable(t *testing.T) {
+	tt := NewTranspositionTable(1000)
+	if len(tt.entries) != 1024 {
+		t.Errorf("Expected table size 1024, found %d", len(tt.entries))
+	}
+
+	move := NewMove(1, Pos{1, 2}, Pos{0, 3})
+	key := NewBoard().Hash(1)
+
+	if _, ok := tt.Probe(key); ok {
+		t.Error("Found an entry in an empty table")
+	}
+
+	tt.Store(key, 4, 17, BoundExact, move)
+	entry, ok := tt.Probe(key)
+	if !ok {
+		t.Fatal("Stored entry not found")
+	}
+
+	if entry.Depth != 4 || entry.Value != 17 || entry.Bound != BoundExact ||
+		!entry.Move.Equals(move) {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+
+	// A shallower result for the same position doesn't replace a deeper one
+	tt.Store(key, 2, 5, BoundLower, move)
+	if entry, _ = tt.Probe(key); entry.Depth != 4 {
+		t.Errorf("Deeper entry replaced by shallower one")
+	}
+
+	// A different position sharing the slot does
+	other := key + uint64(len(tt.entries))
+	tt.Store(other, 1, 3, BoundUpper, nil)
+	if _, ok = tt.Probe(key); ok {
+		t.Error("Expected entry to be replaced")
+	}
+
+	tt.Clear()
+	if _, ok = tt.Probe(other); ok {
+		t.Error("Found an entry in a cleared table")
+	}
+}
+
+func TestWinValuesInTable(t *testing.T) {
+	// A win 3 plies below a node at ply 5 is stored relative to that node,
+	// and read back relative to wherever the position is found again
+	value := WinScore - 8
+	stored := valueToTT(value, 5)
+	if stored != WinScore-3 {
+		t.Errorf("Expected stored value %d, found %d", WinScore-3, stored)
+	}
+
+	if found := valueFromTT(stored, 2); found != WinScore-5 {
+		t.Errorf("Expected value %d at ply 2, found %d", WinScore-5, found)
+	}
+
+	if valueToTT(42, 5) != 42 || valueFromTT(42, 5) != 42 {
+		t.Error("Ordinary values must not be adjusted")
+	}
+}
+
+func TestSearchWithTableAgrees(t *testing.T) {
+	// The table mustn't change which tactical win is found
+	board := NewBoardFromArray([8][8]int{
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 1, 0, 0, 0, 0},
+		{0, 0, 0, 0, 2, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+	})
+
+	with := NewEngine(DefaultTableSize).Search(board, 1, SearchLimits{Depth: 6})
+	without := NewEngine(0).Search(board, 1, SearchLimits{Depth: 6})
+	if with.Value != without.Value || !with.Move.Equals(without.Move) {
+		t.Errorf("Search with table found %d, without %d", with.Value, without.Value)
+	}
+}
+
+func TestTranspositionTableConcurrent(t *testing.T) {
+	// Goroutines storing and probing the same slots only ever see whole
+	// entries, each with its own key's value
+	tt := NewTranspositionTable(64)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 10000; i++ {
+				key := uint64(i*8 + g)
+				tt.Store(key, 1, int(key), BoundExact, nil)
+				if entry, ok := tt.Probe(uint64(i)); ok && entry.Value != int(entry.Key) {
+					t.Errorf("Entry for %d has value %d", entry.Key, entry.Value)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
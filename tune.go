@@ -0,0 +1,203 @@
+package draughts
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Weights are tuned the way Texel's were: every quiet position from a
+// collection of games is labelled with how the game went for the player to
+// move, and the weights are adjusted, a step at a time, for as long as that
+// makes the evaluation a better predictor of the result. The evaluation
+// becomes a predicted score through the logistic function, with a scale K
+// fitted to the weights we start from.
+
+// numFeatures is the number of weights in Weights
+const numFeatures = 5
+
+// features are a position's differences, in the order of Weights.vector
+func (be *boardEval) features() [numFeatures]int {
+	return [numFeatures]int{be.PieceDiff, be.KingDiff, be.MovesCountDiff, be.CapturableDiff, be.KingMakerDiff}
+}
+
+// vector lists the weights in the order of boardEval.features
+func (w Weights) vector() [numFeatures]int {
+	return [numFeatures]int{w.Piece, w.King, w.MovesCount, w.Capturable, w.KingMaker}
+}
+
+// weightsFromVector is the inverse of Weights.vector
+func weightsFromVector(v [numFeatures]int) Weights {
+	return Weights{Piece: v[0], King: v[1], MovesCount: v[2], Capturable: v[3], KingMaker: v[4]}
+}
+
+// TOML writes the weights as ParseWeights reads them
+func (w Weights) TOML() string {
+	lines := []string{
+		fmt.Sprintf("piece = %d", w.Piece),
+		fmt.Sprintf("king = %d", w.King),
+		fmt.Sprintf("moves_count = %d", w.MovesCount),
+		fmt.Sprintf("capturable = %d", w.Capturable),
+		fmt.Sprintf("king_maker = %d", w.KingMaker),
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// TuningPosition is a position to tune with: what the evaluation sees of
+// it, and how the game went for the player to move, 1 for a win, 0.5 for a
+// draw and 0 for a loss
+type TuningPosition struct {
+	features [numFeatures]int
+	Result   float64
+}
+
+// TuningPositions takes the positions to tune with from `games`, skipping
+// the first `skip` plies of each, which are likely to come from an opening
+// book, and any position with a capture to make, whose value depends on
+// what's taken. Games without a result, and of variants other than
+// English, are left out. So are any that can't be replayed, as a big
+// collection will have a few with mistakes in; it returns how many.
+func TuningPositions(games []*PDNGame, skip int) ([]TuningPosition, int) {
+	positions := []TuningPosition{}
+	skipped := 0
+	for _, pdn := range games {
+		v, err := pdn.Variant()
+		if err != nil {
+			skipped++
+			continue
+		}
+		if v != English {
+			continue
+		}
+
+		winner := 0
+		switch pdn.Result {
+		case WinResult(1):
+			winner = 1
+		case WinResult(2):
+			winner = 2
+		case ResultDraw:
+		default:
+			continue
+		}
+
+		board, moves, err := pdn.Replay()
+		if err != nil {
+			skipped++
+			continue
+		}
+
+		for ply, move := range moves {
+			player := move.Player
+			if ply >= skip && !board.Bitboard().CanCapture(player) {
+				result := 0.5
+				if winner != 0 {
+					result = 0
+					if winner == player {
+						result = 1
+					}
+				}
+				positions = append(positions, TuningPosition{eval(board, player).features(), result})
+			}
+			board = board.Apply(move)
+		}
+	}
+
+	return positions, skipped
+}
+
+// evaluate is the value of a position with weights `v`
+func (tp TuningPosition) evaluate(v [numFeatures]int) int {
+	value := 0
+	for i, feature := range tp.features {
+		value += feature * v[i]
+	}
+	return value
+}
+
+// predictedScore turns an evaluation into the score expected from it
+func predictedScore(value int, k float64) float64 {
+	return 1 / (1 + math.Pow(10, -k*float64(value)/400))
+}
+
+// TuningError is how badly the weights predict the results: the mean
+// squared difference between the result and the predicted score, with
+// scale `k`
+func TuningError(positions []TuningPosition, w Weights, k float64) float64 {
+	if len(positions) == 0 {
+		return 0
+	}
+
+	v := w.vector()
+	total := 0.0
+	for _, tp := range positions {
+		diff := tp.Result - predictedScore(tp.evaluate(v), k)
+		total += diff * diff
+	}
+	return total / float64(len(positions))
+}
+
+// FitScale finds the scale K that best fits the weights to the results,
+// by golden section search
+func FitScale(positions []TuningPosition, w Weights) float64 {
+	// Searching over log K, as the best may be anything from tiny to large
+	low, high := math.Log(1e-3), math.Log(1e3)
+	ratio := (math.Sqrt(5) - 1) / 2
+	errorAt := func(logK float64) float64 {
+		return TuningError(positions, w, math.Exp(logK))
+	}
+
+	a, b := high-ratio*(high-low), low+ratio*(high-low)
+	errA, errB := errorAt(a), errorAt(b)
+	for high-low > 1e-4 {
+		if errA < errB {
+			high, b, errB = b, a, errA
+			a = high - ratio*(high-low)
+			errA = errorAt(a)
+		} else {
+			low, a, errA = a, b, errB
+			b = low + ratio*(high-low)
+			errB = errorAt(b)
+		}
+	}
+
+	return math.Exp((low + high) / 2)
+}
+
+// Tune adjusts the weights, starting from `start`, to predict the results
+// of `positions` as well as it can. Each pass tries every weight a step up
+// and a step down, keeping whatever helps, until nothing does or `passes`
+// have been made. `progress`, if not nil, is told the weights and error
+// after each pass. It returns the weights, their error and the scale K.
+func Tune(positions []TuningPosition, start Weights, passes int, progress func(int, Weights, float64)) (Weights, float64, float64) {
+	k := FitScale(positions, start)
+	best := start.vector()
+	bestErr := TuningError(positions, start, k)
+
+	for pass := 1; pass <= passes; pass++ {
+		improved := false
+		for i := range best {
+			for _, step := range []int{1, -1} {
+				for {
+					candidate := best
+					candidate[i] += step
+					err := TuningError(positions, weightsFromVector(candidate), k)
+					if err >= bestErr {
+						break
+					}
+
+					best, bestErr, improved = candidate, err, true
+				}
+			}
+		}
+
+		if progress != nil {
+			progress(pass, weightsFromVector(best), bestErr)
+		}
+		if !improved {
+			break
+		}
+	}
+
+	return weightsFromVector(best), bestErr, k
+}
@@ -0,0 +1,79 @@
+package draughts
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+const tunePDN = `[Result "0-1"]
+1. 11-15 23-19 2. 8-11 22-17 0-1
+
+[Result "*"]
+1. 11-15 23-19 *
+
+[Result "1-0"]
+1. 11-15 23-26 1-0
+
+[GameType "20"]
+[Result "1-0"]
+1. 32-28 19-23 1-0
+`
+
+func TestTuningPositions(t *testing.T) {
+	games, err := ParsePDN(strings.NewReader(tunePDN))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the first game counts, less its first ply. Red won, and Green
+	// is to move after 11-15. The one with an illegal move is skipped.
+	positions, skipped := TuningPositions(games, 1)
+	if skipped != 1 {
+		t.Errorf("Expected 1 game skipped, found %d", skipped)
+	}
+	expected := []float64{0, 1, 0}
+	if len(positions) != len(expected) {
+		t.Fatalf("Expected %d positions, found %d", len(expected), len(positions))
+	}
+	for i, tp := range positions {
+		if tp.Result != expected[i] {
+			t.Errorf("Position %d: expected result %v, found %v", i, expected[i], tp.Result)
+		}
+	}
+}
+
+func TestTune(t *testing.T) {
+	// Results that go with the piece difference, and a moves difference
+	// that's just noise
+	rng := rand.New(rand.NewSource(1))
+	positions := []TuningPosition{}
+	for i := 0; i < 1000; i++ {
+		pieces := rng.Intn(7) - 3
+		result := 0.5
+		if pieces > 0 {
+			result = 1
+		} else if pieces < 0 {
+			result = 0
+		}
+		positions = append(positions, TuningPosition{[numFeatures]int{pieces, 0, rng.Intn(11) - 5, 0, 0}, result})
+	}
+
+	start := Weights{Piece: 5, MovesCount: 5}
+	weights, tuned, k := Tune(positions, start, 50, nil)
+	if before := TuningError(positions, start, k); tuned >= before {
+		t.Errorf("Expected tuning to reduce the error from %f, found %f", before, tuned)
+	}
+	if weights.Piece <= start.Piece || weights.MovesCount >= start.MovesCount {
+		t.Errorf("Expected the piece weight up and the moves weight down, found %+v", weights)
+	}
+
+	// The weights are written so they can be read back
+	parsed, err := ParseWeights([]byte(weights.TOML()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed != weights {
+		t.Errorf("Expected %+v back, found %+v", weights, parsed)
+	}
+}
@@ -0,0 +1,184 @@
+package draughts
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxSize is the largest board supported by any variant
+const MaxSize = 10
+
+// Variant describes the rules of a particular draughts game. Everything
+// else -- move generation, validation, search -- works off these.
+type Variant struct {
+	Name     string
+	GameType int // PDN GameType tag value
+	Size     int // Board is Size x Size
+
+	// FirstPlayer moves first. In English draughts that's the side at the
+	// top of the board (squares 1-12), in the others it's the side at the
+	// bottom.
+	FirstPlayer int
+
+	FlyingKings          bool // Kings move and capture over any distance
+	MenCaptureBackward   bool // Men may capture backwards, as well as forwards
+	MaximumCapture       bool // The capture taking the most pieces must be played
+	PromoteDuringCapture bool // A man reaching the far side mid-capture carries on as a king
+
+	// QuietMoves is how many moves each player may make without a capture
+	// or a man moving before the game is drawn
+	QuietMoves int
+
+	// ShortEndgames draws a few pieces against a lone king, as the FMJD's
+	// rules do, after 16 moves each with three pieces, 5 with two or one
+	ShortEndgames bool
+}
+
+// The variants we know about
+var (
+	English = &Variant{
+		Name:        "english",
+		GameType:    21,
+		Size:        8,
+		FirstPlayer: 1,
+		QuietMoves:  40,
+	}
+
+	International = &Variant{
+		Name:               "international",
+		GameType:           20,
+		Size:               10,
+		FirstPlayer:        2,
+		FlyingKings:        true,
+		MenCaptureBackward: true,
+		MaximumCapture:     true,
+		QuietMoves:         25,
+		ShortEndgames:      true,
+	}
+
+	Russian = &Variant{
+		Name:                 "russian",
+		GameType:             25,
+		Size:                 8,
+		FirstPlayer:          2,
+		FlyingKings:          true,
+		MenCaptureBackward:   true,
+		PromoteDuringCapture: true,
+		QuietMoves:           40,
+	}
+
+	Brazilian = &Variant{
+		Name:               "brazilian",
+		GameType:           26,
+		Size:               8,
+		FirstPlayer:        2,
+		FlyingKings:        true,
+		MenCaptureBackward: true,
+		MaximumCapture:     true,
+		QuietMoves:         40,
+	}
+
+	Pool = &Variant{
+		Name:               "pool",
+		GameType:           23,
+		Size:               8,
+		FirstPlayer:        2,
+		FlyingKings:        true,
+		MenCaptureBackward: true,
+		QuietMoves:         40,
+	}
+
+	// Variants lists all of the above, in order of GameType
+	Variants = []*Variant{International, English, Pool, Russian, Brazilian}
+)
+
+// VariantByName looks up a variant by its name, as given in Variant.Name
+func VariantByName(name string) (*Variant, error) {
+	for _, v := range Variants {
+		if v.Name == strings.ToLower(name) {
+			return v, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Unknown variant '%s'", name)
+}
+
+// VariantByGameType looks up a variant by its PDN GameType number
+func VariantByGameType(gameType int) (*Variant, error) {
+	for _, v := range Variants {
+		if v.GameType == gameType {
+			return v, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Unsupported GameType %d", gameType)
+}
+
+// bitboardRules is true if the variant plays by English rules on an 8x8
+// board, so moves can be generated from the bitboard
+func (v *Variant) bitboardRules() bool {
+	return v.Size == 8 && !v.FlyingKings && !v.MenCaptureBackward &&
+		!v.MaximumCapture && !v.PromoteDuringCapture
+}
+
+// onBoard checks that `p` falls within the board
+func (v *Variant) onBoard(p Pos) bool {
+	return p.X >= 0 && p.X < v.Size && p.Y >= 0 && p.Y < v.Size
+}
+
+// playable is true for the dark squares, the only ones pieces go on
+func playable(p Pos) bool {
+	return (p.X+p.Y)%2 == 1
+}
+
+// crownRow is the row on which `player`'s men are crowned
+func (v *Variant) crownRow(player int) int {
+	if player == 1 {
+		return v.Size - 1
+	}
+	return 0
+}
+
+// SquareCount is the number of playable squares
+func (v *Variant) SquareCount() int {
+	return v.Size * v.Size / 2
+}
+
+// SquarePos returns the position of a square given by its number in the
+// standard notation, counting from 1 along the top row
+func (v *Variant) SquarePos(squareNumber int) Pos {
+	perRow := v.Size / 2
+	y := (squareNumber - 1) / perRow
+	x := 2 * ((squareNumber - 1) % perRow)
+	if y%2 == 0 {
+		x++
+	}
+
+	return Pos{x, y}
+}
+
+// SquareNumber does the opposite of SquarePos
+func (v *Variant) SquareNumber(p Pos) int {
+	return p.Y*(v.Size/2) + p.X/2 + 1
+}
+
+// ParseMove reads a list of squares separated by space. A square is
+// represented by its numbering in the standard notation.
+func (v *Variant) ParseMove(moveStr string, player int) (*Move, error) {
+	squares := strings.Fields(moveStr)
+	if len(squares) < 2 {
+		return nil, fmt.Errorf("Move must visit at least two squares")
+	}
+
+	move := &Move{Player: player}
+	for _, squareStr := range squares {
+		squareNumber, err := strconv.Atoi(squareStr)
+		if err != nil || squareNumber < 1 || squareNumber > v.SquareCount() {
+			return nil, fmt.Errorf("Bad square '%s'", squareStr)
+		}
+		move.addSquare(v.SquarePos(squareNumber))
+	}
+
+	return move, nil
+}
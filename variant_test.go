@@ -0,0 +1,230 @@
+package draughts
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestInitialPositions(t *testing.T) {
+	for _, v := range Variants {
+		board := NewVariantBoard(v)
+		pc := board.CountPieces()
+
+		expected := v.SquareCount()/2 - v.Size/2
+		if pc.Men[0] != expected || pc.Men[1] != expected {
+			t.Errorf("%s: expected %d men each, found %v", v.Name, expected, pc.Men)
+		}
+	}
+}
+
+func TestSquareNumbering(t *testing.T) {
+	// The general numbering agrees with the English tables
+	for square := 1; square <= 32; square++ {
+		if English.SquarePos(square) != NewPosFromSquareID(square) {
+			t.Errorf("Square %d: expected %s, found %s", square,
+				NewPosFromSquareID(square).AsString(), English.SquarePos(square).AsString())
+		}
+	}
+
+	for _, v := range Variants {
+		for square := 1; square <= v.SquareCount(); square++ {
+			p := v.SquarePos(square)
+			if !playable(p) || !v.onBoard(p) || v.SquareNumber(p) != square {
+				t.Errorf("%s: square %d doesn't round trip via %s",
+					v.Name, square, p.AsString())
+			}
+		}
+	}
+
+	// On the 10x10 board, 46 is the bottom left corner
+	if International.SquarePos(46) != (Pos{0, 9}) {
+		t.Errorf("Expected square 46 at {0, 9}, found %s",
+			International.SquarePos(46).AsString())
+	}
+}
+
+func TestInternationalPerft(t *testing.T) {
+	expected := []int{1, 9, 81, 658, 4265}
+
+	board := NewVariantBoard(International)
+	for depth, count := range expected {
+		if actual := Perft(board, International.FirstPlayer, depth); actual != count {
+			t.Errorf("Depth %d: expected %d positions, found %d", depth, count, actual)
+		}
+	}
+}
+
+// variantBoard sets up a board from a map of square number to piece
+func variantBoard(v *Variant, pieces map[int]int) *Board {
+	board := &Board{variant: v}
+	for square, piece := range pieces {
+		board.Set(v.SquarePos(square), piece)
+	}
+
+	return board
+}
+
+func TestVariantRules(t *testing.T) {
+	type variantTest struct {
+		name     string
+		variant  *Variant
+		pieces   map[int]int
+		player   int
+		expected []string // Every legal move, in any order
+		illegal  []string // Moves CheckUserMove must reject
+	}
+
+	var variantTests = []variantTest{
+		{
+			"men capture backwards",
+			Russian,
+			map[int]int{22: 1, 18: 2, 4: 2},
+			1,
+			[]string{"22x15"},
+			[]string{"22-26"},
+		},
+		{
+			"man crowned mid-capture carries on as a king",
+			Russian,
+			map[int]int{22: 1, 26: 2, 27: 2},
+			1,
+			[]string{"22x31x24", "22x31x20"},
+			[]string{"22x31"},
+		},
+		{
+			"man passing the far side carries on as a man",
+			Brazilian,
+			map[int]int{22: 1, 26: 2, 27: 2},
+			1,
+			[]string{"22x31x24"},
+			[]string{"22x31", "22x31x20"},
+		},
+		{
+			"flying king lands anywhere beyond",
+			Russian,
+			map[int]int{1: -1, 15: 2, 32: 2},
+			1,
+			[]string{"1x19", "1x24", "1x28"},
+			[]string{"1x10", "1-6"},
+		},
+		{
+			"flying king must land where it can carry on capturing",
+			Russian,
+			map[int]int{1: -1, 15: 2, 27: 2},
+			1,
+			[]string{"1x24x31"},
+			[]string{"1x19", "1x28", "1x24"},
+		},
+		{
+			"flying king moves any distance",
+			Pool,
+			map[int]int{1: -1, 28: 2},
+			1,
+			[]string{"1-5", "1-6", "1-10", "1-15", "1-19", "1-24"},
+			[]string{"1-28"},
+		},
+		{
+			"free choice of capture",
+			Pool,
+			map[int]int{26: 2, 22: 1, 23: 1, 15: 1},
+			2,
+			[]string{"26x17", "26x19x10"},
+			[]string{"26-21"},
+		},
+		{
+			"the capture taking the most pieces must be played",
+			International,
+			map[int]int{32: 2, 27: 1, 28: 1, 19: 1},
+			2,
+			[]string{"32x23x14"},
+			[]string{"32x21", "32x23"},
+		},
+	}
+
+	for _, tt := range variantTests {
+		board := variantBoard(tt.variant, tt.pieces)
+		allMoves := board.AllMoves(tt.player)
+
+		actual := []string{}
+		for _, move := range allMoves {
+			actual = append(actual, board.MoveString(move))
+		}
+		sort.Strings(actual)
+
+		expected := append([]string{}, tt.expected...)
+		sort.Strings(expected)
+
+		if strings.Join(actual, " ") != strings.Join(expected, " ") {
+			t.Errorf("%s: expected %v, found %v", tt.name, expected, actual)
+		}
+
+		for _, moveStr := range tt.expected {
+			move, _ := tt.variant.ParseMove(strings.NewReplacer("x", " ", "-", " ").Replace(moveStr), tt.player)
+			if err := CheckUserMove(board, allMoves, move); err != nil {
+				t.Errorf("%s: legal move %s reported invalid (%s)", tt.name, moveStr, err)
+			}
+		}
+
+		for _, moveStr := range tt.illegal {
+			move, _ := tt.variant.ParseMove(strings.NewReplacer("x", " ", "-", " ").Replace(moveStr), tt.player)
+			if CheckUserMove(board, allMoves, move) == nil {
+				t.Errorf("%s: illegal move %s reported valid", tt.name, moveStr)
+			}
+		}
+	}
+}
+
+func TestVariantPromotion(t *testing.T) {
+	// Passing through the far side crowns in Russian draughts..
+	board := variantBoard(Russian, map[int]int{22: 1, 26: 2, 27: 2})
+	board = board.Apply(board.AllMoves(1)[0])
+	if pc := board.CountPieces(); pc.Kings[0] != 1 || pc.Men[1]+pc.Kings[1] != 0 {
+		t.Errorf("Expected one red king and no green pieces, found %+v", pc)
+	}
+
+	// ..but not in Brazilian
+	board = variantBoard(Brazilian, map[int]int{22: 1, 26: 2, 27: 2})
+	board = board.Apply(board.AllMoves(1)[0])
+	if pc := board.CountPieces(); pc.Men[0] != 1 || pc.Men[1]+pc.Kings[1] != 0 {
+		t.Errorf("Expected one red man and no green pieces, found %+v", pc)
+	}
+}
+
+func TestVariantGeneratedMovesValidate(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for _, v := range Variants {
+		for game := 0; game < 20; game++ {
+			board := NewVariantBoard(v)
+			player := v.FirstPlayer
+			for ply := 0; ply < 150; ply++ {
+				moves := board.AllMoves(player)
+				if len(moves) == 0 {
+					break
+				}
+
+				for _, move := range moves {
+					if err := CheckUserMove(board, moves, move); err != nil {
+						t.Fatalf("%s: generated move %s reported invalid (%s)",
+							v.Name, board.MoveString(move), err)
+					}
+				}
+
+				move := moves[rng.Intn(len(moves))]
+				before := board.CountPieces()
+				captured := len(board.CapturedSquares(move))
+				board = board.Apply(move)
+				after := board.CountPieces()
+
+				lost := before.Men[Opposition(player)-1] + before.Kings[Opposition(player)-1] -
+					after.Men[Opposition(player)-1] - after.Kings[Opposition(player)-1]
+				if lost != captured {
+					t.Fatalf("%s: move %s captured %d pieces, but %d were removed",
+						v.Name, board.MoveString(move), captured, lost)
+				}
+				player = Opposition(player)
+			}
+		}
+	}
+}
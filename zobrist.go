@@ -0,0 +1,68 @@
+package draughts
+
+// Zobrist hashing assigns a random 64 bit key to every (square, piece)
+// combination, and one for the side to move. The hash of a position is the
+// XOR of the keys of all pieces on the board, which means it can be
+// updated incrementally: XOR out the old occupant of a square, XOR in the
+// new one.
+
+// zobristSeed is fixed so that hashes are stable between runs, which
+// anything persisted by hash relies on
+const zobristSeed = 0x2545F4914F6CDD1D
+
+var (
+	zobristKeys [MaxSize][MaxSize][5]uint64 // Indexed by piece value + 2
+	zobristSide uint64                      // XOR-ed in when player 2 is to move
+)
+
+func init() {
+	rng := splitMix64(zobristSeed)
+	for y := 0; y < MaxSize; y++ {
+		for x := 0; x < MaxSize; x++ {
+			for piece := -2; piece <= 2; piece++ {
+				if piece != 0 {
+					zobristKeys[y][x][piece+2] = rng()
+				}
+			}
+		}
+	}
+	zobristSide = rng()
+}
+
+// splitMix64 returns a simple, fast generator of well mixed 64 bit values
+func splitMix64(seed uint64) func() uint64 {
+	state := seed
+	return func() uint64 {
+		state += 0x9E3779B97F4A7C15
+		z := state
+		z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+		z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+		return z ^ (z >> 31)
+	}
+}
+
+// zobristPiece is the key for `piece` on square `p`. Empty squares don't
+// contribute to the hash.
+func zobristPiece(p Pos, piece int) uint64 {
+	return zobristKeys[p.Y][p.X][piece+2]
+}
+
+// computeHash calculates the hash of the pieces from scratch
+func (b Board) computeHash() uint64 {
+	var hash uint64
+	for y := 0; y < b.Variant().Size; y++ {
+		for x := 0; x < b.Variant().Size; x++ {
+			hash ^= zobristPiece(Pos{x, y}, b.state[y][x])
+		}
+	}
+
+	return hash
+}
+
+// Hash returns the Zobrist hash of the position with `player` to move
+func (b Board) Hash(player int) uint64 {
+	if player == 2 {
+		return b.hash ^ zobristSide
+	}
+	return b.hash
+}
@@ -0,0 +1,51 @@
+package draughts
+
+import "testing"
+
+func TestIncrementalHash(t *testing.T) {
+	board := NewBoard()
+	player := 1
+
+	// Play out the first move in every position for a while, checking the
+	// incrementally updated hash against one computed from scratch
+	for ply := 0; ply < 40; ply++ {
+		moves := board.AllMoves(player)
+		if len(moves) == 0 {
+			break
+		}
+
+		board = board.Apply(moves[ply%len(moves)])
+		player = Opposition(player)
+
+		if board.hash != board.computeHash() {
+			t.Fatalf("Incremental hash differs from computed hash at ply %d", ply)
+		}
+	}
+}
+
+func TestHashTransposition(t *testing.T) {
+	// The same position reached via two move orders has the same hash
+	board1 := NewBoard().
+		Apply(NewMove(1, NewPosFromSquareID(9), NewPosFromSquareID(13))).
+		Apply(NewMove(2, NewPosFromSquareID(21), NewPosFromSquareID(17))).
+		Apply(NewMove(1, NewPosFromSquareID(10), NewPosFromSquareID(14))).
+		Apply(NewMove(2, NewPosFromSquareID(22), NewPosFromSquareID(18)))
+
+	board2 := NewBoard().
+		Apply(NewMove(1, NewPosFromSquareID(10), NewPosFromSquareID(14))).
+		Apply(NewMove(2, NewPosFromSquareID(22), NewPosFromSquareID(18))).
+		Apply(NewMove(1, NewPosFromSquareID(9), NewPosFromSquareID(13))).
+		Apply(NewMove(2, NewPosFromSquareID(21), NewPosFromSquareID(17)))
+
+	if board1.Hash(1) != board2.Hash(1) {
+		t.Error("Transposed positions have different hashes")
+	}
+
+	if board1.Hash(1) == board1.Hash(2) {
+		t.Error("Side to move doesn't change the hash")
+	}
+
+	if NewBoard().Hash(1) == board1.Hash(1) {
+		t.Error("Different positions have the same hash")
+	}
+}